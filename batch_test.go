@@ -0,0 +1,59 @@
+package frost_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchVerifier(t *testing.T) {
+	bv := frost.NewBatchVerifier(csuite)
+	ok, invalid, err := bv.Verify()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, invalid)
+
+	keygen, sig := thresholdSign(t, []byte("threshold"))
+	bv.Add(keygen.GroupPublicKey, []byte("threshold"), sig)
+
+	for i := range 20 {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		msg := fmt.Appendf(nil, "message %d", i)
+		s, err := frost.SignatureFromBytes(ed25519.Sign(priv, msg))
+		require.NoError(t, err)
+		gk, err := frost.GroupKeyFromBytes(pub)
+		require.NoError(t, err)
+		if i == 4 || i == 17 {
+			msg = []byte("tampered")
+		}
+		bv.Add(gk, msg, s)
+	}
+	require.Equal(t, 21, bv.Len())
+
+	ok, invalid, err = bv.Verify()
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, []int{5, 18}, invalid)
+}
+
+func TestBatchVerifierAllValid(t *testing.T) {
+	bv := frost.NewBatchVerifier(csuite)
+	for i := range 3 {
+		msg := fmt.Appendf(nil, "release %d", i)
+		keygen, sig := thresholdSign(t, msg)
+		bv.Add(keygen.GroupPublicKey, msg, sig)
+	}
+	ok, invalid, err := bv.Verify()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, invalid)
+
+	bv.Add(nil, []byte("x"), nil)
+	_, _, err = bv.Verify()
+	require.Error(t, err)
+}
@@ -0,0 +1,198 @@
+package frost_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBORRoundTrip(t *testing.T) {
+	msg := []byte("cbor")
+	keygen, sig := thresholdSign(t, msg)
+	state := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[0])
+	com, err := state.Commit()
+	require.NoError(t, err)
+	share := &frost.SignatureShare{Identifier: com.Identifier, Share: sig.Z}
+
+	// [R, z]: an array of two 32-byte strings
+	enc := sig.EncodeCBOR()
+	require.Len(t, enc, 1+2*34)
+	require.Equal(t, []byte{0x82, 0x58, 0x20}, enc[:3])
+	require.Equal(t, []byte{0x58, 0x20}, enc[35:37])
+
+	roundTrip := func(enc []byte, decode func([]byte) ([]byte, error)) {
+		t.Helper()
+		again, err := decode(enc)
+		require.NoError(t, err)
+		require.Equal(t, enc, again)
+		_, err = decode(enc[:len(enc)-1])
+		require.Error(t, err)
+		_, err = decode(append(append([]byte{}, enc...), 0))
+		require.Error(t, err)
+	}
+	roundTrip(sig.EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.SignatureFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+	roundTrip(sig.Z.EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.ScalarFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+	roundTrip(sig.R.EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.ElementFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+	roundTrip(keygen.GroupPublicKey.EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.GroupKeyFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+	roundTrip(keygen.Participants[1].EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.ParticipantFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+	roundTrip(keygen.ParticipantPrivateKeys[1].EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.SecretShareFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+	roundTrip(com.EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.CommitmentFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+	roundTrip(share.EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.SignatureShareFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+	roundTrip(frost.EncodeVSSCommitmentCBOR(keygen.VssCommitment), func(b []byte) ([]byte, error) {
+		v, err := frost.VSSCommitmentFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return frost.EncodeVSSCommitmentCBOR(v), nil
+	})
+	roundTrip(keygen.EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.KeygenOutputFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+
+	seed, err := frost.NewRandomizerSeed(nil)
+	require.NoError(t, err)
+	pkg := &frost.SigningPackage{
+		SessionID:      []byte("sid"),
+		Message:        msg,
+		Commitments:    []*frost.Commitment{com},
+		RandomizerSeed: seed,
+		DerivationPath: [][]byte{[]byte("a"), []byte("b")},
+		AdaptorPoint:   sig.R,
+		BlindChallenge: sig.Z,
+	}
+	roundTrip(pkg.EncodeCBOR(), func(b []byte) ([]byte, error) {
+		v, err := frost.SigningPackageFromCBOR(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeCBOR(), nil
+	})
+
+	for _, m := range []*frost.Message{
+		{Type: frost.MessageTypeCommitment, SessionID: []byte("sid"), From: com.Identifier, Commitment: com},
+		{Type: frost.MessageTypeSigningPackage, SessionID: []byte("sid"), SigningPackage: pkg},
+		{Type: frost.MessageTypeSignatureShare, From: share.Identifier, To: keygen.Participants[2].Identifier, SignatureShare: share},
+		{Type: frost.MessageTypeKeyGen2, From: com.Identifier, Payload: []byte{}},
+	} {
+		enc, err := m.EncodeCBOR()
+		require.NoError(t, err)
+		roundTrip(enc, func(b []byte) ([]byte, error) {
+			v, err := frost.MessageFromCBOR(b)
+			if err != nil {
+				return nil, err
+			}
+			return v.EncodeCBOR()
+		})
+	}
+}
+
+func TestCBORRejectsNonDeterministic(t *testing.T) {
+	_, sig := thresholdSign(t, []byte("deterministic"))
+	enc := sig.EncodeCBOR()
+
+	// A 32-byte string with a 2-byte length
+	long := append([]byte{0x82, 0x59, 0x00, 0x20}, enc[3:]...)
+	_, err := frost.SignatureFromCBOR(long)
+	require.Error(t, err)
+
+	// An indefinite-length array
+	indefinite := append(append([]byte{0x9f}, enc[1:]...), 0xff)
+	_, err = frost.SignatureFromCBOR(indefinite)
+	require.Error(t, err)
+
+	// Map keys out of order: {2: h'', 1: h'00'} and an unknown key
+	_, err = frost.SigningPackageFromCBOR([]byte{0xa2, 0x02, 0x40, 0x01, 0x41, 0x00})
+	require.Error(t, err)
+	_, err = frost.SigningPackageFromCBOR([]byte{0xa3, 0x02, 0x40, 0x03, 0x80, 0x08, 0x40})
+	require.Error(t, err)
+
+	// The smallest valid package: {2: h'', 3: []}
+	pkg, err := frost.SigningPackageFromCBOR([]byte{0xa2, 0x02, 0x40, 0x03, 0x80})
+	require.NoError(t, err)
+	require.Empty(t, pkg.Commitments)
+
+	// Repeated commitments
+	keygen, _ := thresholdSign(t, []byte("cbor keygen"))
+	com, err := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, nil, keygen.ParticipantPrivateKeys[0]).Commit()
+	require.NoError(t, err)
+	repeated := &frost.SigningPackage{Commitments: []*frost.Commitment{com, com}}
+	_, err = frost.SigningPackageFromCBOR(repeated.EncodeCBOR())
+	require.Error(t, err)
+
+	// Key generation output with an identity group key or no VSS commitment
+	identity := *keygen
+	identity.GroupPublicKey = &frost.GroupKey{Element: frost.NewElement()}
+	_, err = frost.KeygenOutputFromCBOR(identity.EncodeCBOR())
+	require.Error(t, err)
+	noVSS := *keygen
+	noVSS.VssCommitment = nil
+	_, err = frost.KeygenOutputFromCBOR(noVSS.EncodeCBOR())
+	require.Error(t, err)
+
+	// An array length whose size in bytes overflows 64 bits
+	huge := binary.BigEndian.AppendUint64([]byte{0x9b}, 558992244657865201)
+	_, err = frost.VSSCommitmentFromCBOR(append(huge, make([]byte, 20)...))
+	require.Error(t, err)
+
+	// A payload that does not match the message type
+	share := &frost.SignatureShare{Identifier: sig.Z, Share: sig.Z}
+	m, err := (&frost.Message{Type: frost.MessageTypeSignatureShare, From: sig.Z, SignatureShare: share}).EncodeCBOR()
+	require.NoError(t, err)
+	m[2] = byte(frost.MessageTypeCommitment)
+	_, err = frost.MessageFromCBOR(m)
+	require.Error(t, err)
+}
@@ -1,19 +1,103 @@
 package frost
 
 import (
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"time"
+
 	"github.com/soatok/frost/internal"
 )
 
+type BatchCoordinator = internal.BatchCoordinator
+type BlindRequest = internal.BlindRequest
+type BlindSigner = internal.BlindSigner
+type BroadcastSigner = internal.BroadcastSigner
+type BatchState = internal.BatchState
+type BatchVerifier = internal.BatchVerifier
 type Ciphersuite = internal.Ciphersuite
 type Commitment = internal.Commitment
+type Coordinator = internal.Coordinator
+type CulpritError = internal.CulpritError
+type Dispatcher = internal.Dispatcher
 type Element = internal.Element
 type GroupKey = internal.GroupKey
+type Handler = internal.Handler
+type HandlerFunc = internal.HandlerFunc
+type Message = internal.Message
+type MessageType = internal.MessageType
 type Nonce = internal.Nonce
+type NonceStore = internal.NonceStore
 type Participant = internal.Participant
+type Phase = internal.Phase
+type Policy = internal.Policy
+type PolicyFunc = internal.PolicyFunc
+type PreSignature = internal.PreSignature
+type QuotaPolicy = internal.QuotaPolicy
+type RefusalError = internal.RefusalError
+type ReplayGuard = internal.ReplayGuard
+type Reservation = internal.Reservation
+type Reserver = internal.Reserver
 type Scalar = internal.Scalar
+type Signature = internal.Signature
 type SignatureShare = internal.SignatureShare
 type SecretShare = internal.SecretShare
+type SigningPackage = internal.SigningPackage
+type SigningRequest = internal.SigningRequest
+type Session = internal.Session
 type State = internal.State
+type VerifyMode = internal.VerifyMode
+
+const (
+	PhaseNew        = internal.PhaseNew
+	PhaseCommitted  = internal.PhaseCommitted
+	PhaseSigned     = internal.PhaseSigned
+	PhaseAggregated = internal.PhaseAggregated
+	PhaseFailed     = internal.PhaseFailed
+)
+
+const (
+	MessageTypeKeyGen1        = internal.MessageTypeKeyGen1
+	MessageTypeKeyGen2        = internal.MessageTypeKeyGen2
+	MessageTypeCommitment     = internal.MessageTypeCommitment
+	MessageTypeSigningPackage = internal.MessageTypeSigningPackage
+	MessageTypeSignatureShare = internal.MessageTypeSignatureShare
+)
+
+const (
+	// RFC 9591 verification: [8][z]B = [8]R + [8][c]PK
+	VerifyCofactored = internal.VerifyCofactored
+	// crypto/ed25519 verification: [z]B = R + [c]PK
+	VerifyCofactorless = internal.VerifyCofactorless
+)
+
+// Length of a randomizer seed for re-randomized FROST
+const RandomizerSeedSize = internal.RandomizerSeedSize
+
+var (
+	// A nonce was used twice, or attached to more than one State
+	ErrNonceReused = internal.ErrNonceReused
+	// Sign was called twice on the same State
+	ErrAlreadySigned = internal.ErrAlreadySigned
+	// Sign was called on a State without a nonce
+	ErrNoNonce = internal.ErrNoNonce
+	// A NonceStore has no nonce for the requested commitment
+	ErrNonceNotFound = internal.ErrNonceNotFound
+	// A Session round was called in the wrong phase
+	ErrOutOfOrder = internal.ErrOutOfOrder
+	// A Session's context is done
+	ErrSessionExpired = internal.ErrSessionExpired
+	// A signature share failed verification
+	ErrInvalidShare = internal.ErrInvalidShare
+	// A signer's Policy refused to release a share
+	ErrRefused = internal.ErrRefused
+	// A message names a session with no registered handler
+	ErrUnknownSession = internal.ErrUnknownSession
+	// A message is addressed to another participant
+	ErrWrongRecipient = internal.ErrWrongRecipient
+	// A BlindSigner already has MaxSessions sessions open
+	ErrTooManyBlindSessions = internal.ErrTooManyBlindSessions
+)
 
 // FROST(Ed25519, SHA-512) from RFC 9591, section 6.1
 type Ed25519Sha512 = internal.Ed25519Sha512
@@ -86,6 +170,14 @@ func SecretShareFromBytes(id, sec []byte) (*SecretShare, error) {
 	return ss, nil
 }
 
+// Initialize a new FROST State that draws its nonce randomness from r instead
+// of crypto/rand. This is meant for reproducing test vectors.
+func NewStateWithReader(c Ciphersuite, participants []*Participant, groupKey *GroupKey, msg []byte, mySecretShare *SecretShare, r io.Reader) *State {
+	s := NewState(c, participants, groupKey, msg, mySecretShare)
+	s.Rand = r
+	return s
+}
+
 // Initialize a new FROST State
 func NewState(c Ciphersuite, participants []*Participant, groupKey *GroupKey, msg []byte, mySecretShare *SecretShare) *State {
 	var myIdentifier *Scalar
@@ -95,16 +187,144 @@ func NewState(c Ciphersuite, participants []*Participant, groupKey *GroupKey, ms
 	return internal.NewState(c, participants, groupKey, msg, myIdentifier, mySecretShare)
 }
 
+// Generate k nonces ahead of time, saving them to the store. The returned
+// commitments should be published to the coordinator.
+func Preprocess(c Ciphersuite, mySecretShare *SecretShare, k int, store NonceStore) ([]*Commitment, error) {
+	return internal.Preprocess(c, mySecretShare, k, store, nil)
+}
+
+// Generate k nonces ahead of time like Preprocess, drawing their randomness
+// from r instead of crypto/rand. This is meant for reproducing test vectors.
+func PreprocessWithReader(c Ciphersuite, mySecretShare *SecretShare, k int, store NonceStore, r io.Reader) ([]*Commitment, error) {
+	return internal.Preprocess(c, mySecretShare, k, store, r)
+}
+
+// Restore a State that was saved with State.Export. The guard ensures the same
+// export can never be restored twice.
+func RestoreState(c Ciphersuite, blob, key []byte, mySecretShare *SecretShare, guard ReplayGuard) (*State, error) {
+	return internal.RestoreState(c, blob, key, mySecretShare, guard)
+}
+
+// Generate a randomizer seed for re-randomized FROST. A nil reader means
+// crypto/rand.
+func NewRandomizerSeed(r io.Reader) ([]byte, error) {
+	return internal.NewRandomizerSeed(r)
+}
+
+// Derive the randomizer for a signing package from a seed
+func DeriveRandomizer(seed []byte, pkg *SigningPackage) *Scalar {
+	return internal.DeriveRandomizer(seed, pkg)
+}
+
+// The group key a re-randomized signature verifies under: PK + [alpha]B
+func RandomizeGroupKey(groupKey *GroupKey, randomizer *Scalar) *GroupKey {
+	return internal.RandomizeGroupKey(groupKey, randomizer)
+}
+
+// Compute the child of a group key along a derivation path. Anyone holding the
+// parent group key can do this.
+func DeriveChildKey(parent *GroupKey, path ...[]byte) *GroupKey {
+	child, _ := internal.DeriveChildKey(parent, path...)
+	return child
+}
+
+// Verify a pre-signature against the group public key and its adaptor point
+func VerifyPreSignature(c Ciphersuite, groupKey *GroupKey, msg []byte, pre *PreSignature) (bool, error) {
+	if groupKey == nil {
+		return false, errors.New("missing group key")
+	}
+	return internal.VerifyPreSignature(c, groupKey.Element, msg, pre)
+}
+
+// Start a blind signing request for msg. A nil reader means crypto/rand.
+func NewBlindRequest(c Ciphersuite, groupKey *GroupKey, msg []byte, r io.Reader) (*BlindRequest, error) {
+	return internal.NewBlindRequest(c, groupKey, msg, r)
+}
+
+// Opt a signer in to blind signing, with at most maxSessions sessions open at
+// once. Only 1 is safe: concurrent sessions allow the ROS attack
+func NewBlindSigner(c Ciphersuite, participants []*Participant, groupKey *GroupKey, mySecretShare *SecretShare, maxSessions int) (*BlindSigner, error) {
+	var myIdentifier *Scalar
+	if mySecretShare != nil {
+		myIdentifier = mySecretShare.Identifier
+	}
+	return internal.NewBlindSigner(c, participants, groupKey, myIdentifier, mySecretShare, maxSessions)
+}
+
+// Initialize a signer's state for signing several messages in one ceremony
+func NewBatchState(c Ciphersuite, participants []*Participant, groupKey *GroupKey, msgs [][]byte, mySecretShare *SecretShare) *BatchState {
+	var myIdentifier *Scalar
+	if mySecretShare != nil {
+		myIdentifier = mySecretShare.Identifier
+	}
+	return internal.NewBatchState(c, participants, groupKey, msgs, myIdentifier, mySecretShare)
+}
+
+// Initialize a coordinator for signing several messages in one ceremony
+func NewBatchCoordinator(c Ciphersuite, participants []*Participant, groupKey *GroupKey, threshold int, msgs [][]byte) (*BatchCoordinator, error) {
+	return internal.NewBatchCoordinator(c, participants, groupKey, threshold, msgs)
+}
+
+// Initialize a Coordinator for signing msg with at least threshold signers
+func NewCoordinator(c Ciphersuite, participants []*Participant, groupKey *GroupKey, threshold int, msg []byte) (*Coordinator, error) {
+	return internal.NewCoordinator(c, participants, groupKey, threshold, msg)
+}
+
+// Start a coordinator-less ceremony among the given signers
+func NewBroadcastSigner(state *State, threshold int, signers ...*Scalar) (*BroadcastSigner, error) {
+	return internal.NewBroadcastSigner(state, threshold, signers...)
+}
+
+// Start a Session around a State, which the Session takes over.
+func NewSession(state *State) *Session {
+	return internal.NewSession(state)
+}
+
 // Deserialize commitments from JSON
 func CommitmentFromJSON(j []byte) (*internal.Commitment, error) {
 	return internal.CommitmentFromJSON(j)
 }
 
+// Decode a signing package from its canonical binary encoding
+func SigningPackageFromBytes(b []byte) (*SigningPackage, error) {
+	return internal.SigningPackageFromBytes(b)
+}
+
+// Deserialize a signing package from JSON
+func SigningPackageFromJSON(j []byte) (*SigningPackage, error) {
+	return internal.SigningPackageFromJSON(j)
+}
+
 // Deserialize a signature share from a JSON sequence
 func SignatureShareFromJSON(j []byte) (*internal.SignatureShare, error) {
 	return internal.SignatureShareFromJSON(j)
 }
 
+// Deserialize a signature from its 64-byte encoding
+func SignatureFromBytes(b []byte) (*Signature, error) {
+	return internal.SignatureFromBytes(b)
+}
+
+// Verify a signature against the group public key, using the cofactored
+// equation required by RFC 9591
+func Verify(c Ciphersuite, groupKey *GroupKey, msg []byte, sig *Signature) (bool, error) {
+	return VerifyWithMode(c, groupKey, msg, sig, VerifyCofactored)
+}
+
+// Verify a signature against the group public key with an explicit choice of
+// cofactored or cofactorless verification
+func VerifyWithMode(c Ciphersuite, groupKey *GroupKey, msg []byte, sig *Signature, mode VerifyMode) (bool, error) {
+	if groupKey == nil {
+		return false, errors.New("missing group key")
+	}
+	return internal.Verify(c, groupKey.Element, msg, sig, mode)
+}
+
+// Initialize a verifier that checks many signatures at once
+func NewBatchVerifier(c Ciphersuite) *BatchVerifier {
+	return internal.NewBatchVerifier(c)
+}
+
 // Internalize a new scalar
 func NewScalar() *Scalar {
 	return internal.NewScalar()
@@ -129,3 +349,198 @@ func ComputeBindingFactors(c Ciphersuite, groupPublicKey *GroupKey, commitments
 func ComputeGroupCommitment(commitments []*Commitment, bindingFactors []*internal.BindingFactor) (*Element, error) {
 	return internal.ComputeGroupCommitment(commitments, bindingFactors)
 }
+
+// Combine policies; a request must be approved by all of them
+func AllOf(policies ...Policy) Policy {
+	return internal.AllOf(policies...)
+}
+
+// Only approve messages that start with one of the prefixes
+func AllowPrefixes(prefixes ...[]byte) Policy {
+	return internal.AllowPrefixes(prefixes...)
+}
+
+// Only approve messages of at most n bytes
+func MaxMessageSize(n int) Policy {
+	return internal.MaxMessageSize(n)
+}
+
+// Only approve signing sets that include all of the given participants
+func RequireCoSigners(ids ...*Scalar) Policy {
+	return internal.RequireCoSigners(ids...)
+}
+
+// Approve at most limit signatures per key per UTC day. A nil clock means time.Now.
+func DailyQuota(limit int, now func() time.Time) *QuotaPolicy {
+	return internal.DailyQuota(limit, now)
+}
+
+// Decode a scalar from its binary encoding
+func ScalarFromBinary(b []byte) (*Scalar, error) {
+	return internal.ScalarFromBinary(b)
+}
+
+// Decode an element from its binary encoding
+func ElementFromBinary(b []byte) (*Element, error) {
+	return internal.ElementFromBinary(b)
+}
+
+// Decode a group key from its binary encoding
+func GroupKeyFromBinary(b []byte) (*GroupKey, error) {
+	return internal.GroupKeyFromBinary(b)
+}
+
+// Convert a crypto/ed25519 public key to a group key
+func GroupKeyFromEd25519(pub ed25519.PublicKey) (*GroupKey, error) {
+	return internal.GroupKeyFromEd25519(pub)
+}
+
+// Parse an ssh-ed25519 authorized_keys line, returning the key and comment
+func GroupKeyFromAuthorizedKey(line []byte) (*GroupKey, string, error) {
+	return internal.GroupKeyFromAuthorizedKey(line)
+}
+
+// Parse an OKP JSON Web Key (RFC 8037)
+func GroupKeyFromJWK(j []byte) (*GroupKey, error) {
+	return internal.GroupKeyFromJWK(j)
+}
+
+// Parse a DER-encoded SubjectPublicKeyInfo
+func GroupKeyFromPKIX(der []byte) (*GroupKey, error) {
+	return internal.GroupKeyFromPKIX(der)
+}
+
+// Parse the first PUBLIC KEY block of a PEM file
+func GroupKeyFromPEM(b []byte) (*GroupKey, error) {
+	return internal.GroupKeyFromPEM(b)
+}
+
+// Decode a participant from its binary encoding
+func ParticipantFromBinary(b []byte) (*Participant, error) {
+	return internal.ParticipantFromBinary(b)
+}
+
+// Decode a secret share from its binary encoding
+func SecretShareFromBinary(b []byte) (*SecretShare, error) {
+	return internal.SecretShareFromBinary(b)
+}
+
+// Decode a commitment from its binary encoding
+func CommitmentFromBinary(b []byte) (*Commitment, error) {
+	return internal.CommitmentFromBinary(b)
+}
+
+// Decode a signature share from its binary encoding
+func SignatureShareFromBinary(b []byte) (*SignatureShare, error) {
+	return internal.SignatureShareFromBinary(b)
+}
+
+// Decode a signature from its binary encoding
+func SignatureFromBinary(b []byte) (*Signature, error) {
+	return internal.SignatureFromBinary(b)
+}
+
+// Decode a signing package from its binary encoding
+func SigningPackageFromBinary(b []byte) (*SigningPackage, error) {
+	return internal.SigningPackageFromBinary(b)
+}
+
+// Encode a VSS commitment in binary
+func EncodeVSSCommitment(vss []*Element) []byte {
+	return internal.EncodeVSSCommitment(vss)
+}
+
+// Decode a VSS commitment from its binary encoding
+func VSSCommitmentFromBinary(b []byte) ([]*Element, error) {
+	return internal.VSSCommitmentFromBinary(b)
+}
+
+// Create a Dispatcher for a participant, or for a coordinator if identifier is nil
+func NewDispatcher(identifier *Scalar) *Dispatcher {
+	return internal.NewDispatcher(identifier)
+}
+
+// Decode a message envelope from its binary encoding
+func MessageFromBinary(b []byte) (*Message, error) {
+	return internal.MessageFromBinary(b)
+}
+
+// Decode a message envelope from JSON
+func MessageFromJSON(j []byte) (*Message, error) {
+	return internal.MessageFromJSON(j)
+}
+
+// A Handler that answers signing packages with the State's share
+func SignerHandler(s *State) Handler {
+	return internal.SignerHandler(s)
+}
+
+// A Handler that feeds commitments and shares to a Coordinator
+func CoordinatorHandler(co *Coordinator) Handler {
+	return internal.CoordinatorHandler(co)
+}
+
+// A Handler that feeds peers' commitments and shares to a BroadcastSigner
+func BroadcastHandler(b *BroadcastSigner) Handler {
+	return internal.BroadcastHandler(b)
+}
+
+// Decode a scalar from CBOR
+func ScalarFromCBOR(b []byte) (*Scalar, error) {
+	return internal.ScalarFromCBOR(b)
+}
+
+// Decode an element from CBOR
+func ElementFromCBOR(b []byte) (*Element, error) {
+	return internal.ElementFromCBOR(b)
+}
+
+// Decode a group key from CBOR
+func GroupKeyFromCBOR(b []byte) (*GroupKey, error) {
+	return internal.GroupKeyFromCBOR(b)
+}
+
+// Decode a participant from CBOR
+func ParticipantFromCBOR(b []byte) (*Participant, error) {
+	return internal.ParticipantFromCBOR(b)
+}
+
+// Decode a secret share from CBOR
+func SecretShareFromCBOR(b []byte) (*SecretShare, error) {
+	return internal.SecretShareFromCBOR(b)
+}
+
+// Decode a commitment from CBOR
+func CommitmentFromCBOR(b []byte) (*Commitment, error) {
+	return internal.CommitmentFromCBOR(b)
+}
+
+// Decode a signature share from CBOR
+func SignatureShareFromCBOR(b []byte) (*SignatureShare, error) {
+	return internal.SignatureShareFromCBOR(b)
+}
+
+// Decode a signature from CBOR
+func SignatureFromCBOR(b []byte) (*Signature, error) {
+	return internal.SignatureFromCBOR(b)
+}
+
+// Decode a signing package from CBOR
+func SigningPackageFromCBOR(b []byte) (*SigningPackage, error) {
+	return internal.SigningPackageFromCBOR(b)
+}
+
+// Decode a message envelope from CBOR
+func MessageFromCBOR(b []byte) (*Message, error) {
+	return internal.MessageFromCBOR(b)
+}
+
+// Encode a VSS commitment as CBOR
+func EncodeVSSCommitmentCBOR(vss []*Element) []byte {
+	return internal.EncodeVSSCommitmentCBOR(vss)
+}
+
+// Decode a VSS commitment from CBOR
+func VSSCommitmentFromCBOR(b []byte) ([]*Element, error) {
+	return internal.VSSCommitmentFromCBOR(b)
+}
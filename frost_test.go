@@ -32,6 +32,10 @@ func TestFrostEd25519(t *testing.T) {
 		groupPublicKeyHex           = "15d21ccd7ee42959562fc8aa63224c8851fb3ec85a3faf66040d380fb9738673"
 		p1ParticipantShareHex       = "929dcc590407aae7d388761cddb0c0db6f5627aea8e217f4a033f2ec83d93509"
 		p3ParticipantShareHex       = "d3cb090a075eb154e82fdb4b3cb507f110040905468bb9c46da8bdea643a9a02"
+		p1HidingRandomnessHex       = "0fd2e39e111cdc266f6c0f4d0fd45c947761f1f5d3cb583dfcb9bbaf8d4c9fec"
+		p1BindingRandomnessHex      = "69cd85f631d5f7f2721ed5e40519b1366f340a87c2f6856363dbdcda348a7501"
+		p3HidingRandomnessHex       = "86d64a260059e495d0fb4fcc17ea3da7452391baa494d4b00321098ed2a0062f"
+		p3BindingRandomnessHex      = "13e6b25afb2eba51716a9a7d44130c0dbae0004a9ef8d7b5550c8a0e07c61775"
 		p1HidingNonceHex            = "812d6104142944d5a55924de6d49940956206909f2acaeedecda2b726e630407"
 		p1BindingNonceHex           = "b1110165fc2334149750b28dd813a39244f315cff14d4e89e6142f262ed83301"
 		p3HidingNonceHex            = "c256de65476204095ebdc01bd11dc10e57b36bc96284595b8215222374f99c0e"
@@ -51,6 +55,10 @@ func TestFrostEd25519(t *testing.T) {
 	groupPublicKeyBytes, _ := hex.DecodeString(groupPublicKeyHex)
 	p1ParticipantShareBytes, _ := hex.DecodeString(p1ParticipantShareHex)
 	p3ParticipantShareBytes, _ := hex.DecodeString(p3ParticipantShareHex)
+	p1HidingRandomness, _ := hex.DecodeString(p1HidingRandomnessHex)
+	p1BindingRandomness, _ := hex.DecodeString(p1BindingRandomnessHex)
+	p3HidingRandomness, _ := hex.DecodeString(p3HidingRandomnessHex)
+	p3BindingRandomness, _ := hex.DecodeString(p3BindingRandomnessHex)
 	p1HidingNonceBytes, _ := hex.DecodeString(p1HidingNonceHex)
 	p1BindingNonceBytes, _ := hex.DecodeString(p1BindingNonceHex)
 	p3HidingNonceBytes, _ := hex.DecodeString(p3HidingNonceHex)
@@ -98,13 +106,46 @@ func TestFrostEd25519(t *testing.T) {
 	p3 := &frost.Participant{Identifier: p3ID, PublicKeyShare: p3PublicKey}
 	participants := []*frost.Participant{p1, p3}
 
-	// Create state for participant 1
-	state1 := frost.NewState(csuite, participants, &frost.GroupKey{Element: groupPublicKey}, msg, &frost.SecretShare{Identifier: p1ID, Scalar: p1SecretShare})
-	state1.MyNonce = &frost.Nonce{Hiding: p1HidingNonce, Binding: p1BindingNonce}
+	// Create state for participant 1, with the nonce randomness from the test vectors
+	p1Rand := bytes.NewReader(append(p1HidingRandomness, p1BindingRandomness...))
+	state1 := frost.NewStateWithReader(csuite, participants, &frost.GroupKey{Element: groupPublicKey}, msg, &frost.SecretShare{Identifier: p1ID, Scalar: p1SecretShare}, p1Rand)
 
 	// Create state for participant 3
-	state3 := frost.NewState(csuite, participants, &frost.GroupKey{Element: groupPublicKey}, msg, &frost.SecretShare{Identifier: p3ID, Scalar: p3SecretShare})
-	state3.MyNonce = &frost.Nonce{Hiding: p3HidingNonce, Binding: p3BindingNonce}
+	p3Rand := bytes.NewReader(append(p3HidingRandomness, p3BindingRandomness...))
+	state3 := frost.NewStateWithReader(csuite, participants, &frost.GroupKey{Element: groupPublicKey}, msg, &frost.SecretShare{Identifier: p3ID, Scalar: p3SecretShare}, p3Rand)
+
+	// Round 1 reproduces the nonce commitments
+	p1Commitment, err := state1.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p3Commitment, err := state3.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p1Commitment.Hiding.Equal(p1HidingCommitment) || !p1Commitment.Binding.Equal(p1BindingCommitment) {
+		t.Fatalf("p1 commitment mismatch")
+	}
+	if !p3Commitment.Hiding.Equal(p3HidingCommitment) || !p3Commitment.Binding.Equal(p3BindingCommitment) {
+		t.Fatalf("p3 commitment mismatch")
+	}
+
+	// Explicit nonces produce the same commitments
+	explicitNonces := []*frost.Nonce{
+		{Hiding: p1HidingNonce, Binding: p1BindingNonce},
+		{Hiding: p3HidingNonce, Binding: p3BindingNonce},
+	}
+	for i, sec := range []*frost.Scalar{p1SecretShare, p3SecretShare} {
+		explicit := frost.NewState(csuite, participants, &frost.GroupKey{Element: groupPublicKey}, msg, &frost.SecretShare{Identifier: participants[i].Identifier, Scalar: sec})
+		err = explicit.SetNonce(explicitNonces[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*frost.Commitment{p1Commitment, p3Commitment}[i]
+		if !explicit.MyCommitment.Hiding.Equal(want.Hiding) || !explicit.MyCommitment.Binding.Equal(want.Binding) {
+			t.Fatalf("explicit nonce commitment mismatch for participant %d", i)
+		}
+	}
 
 	// Commitments
 	commitments := []*frost.Commitment{
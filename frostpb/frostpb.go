@@ -0,0 +1,637 @@
+// Package frostpb maps FROST objects to and from the Protocol Buffers
+// messages in frost.proto. The codec is written directly against protowire,
+// so no generated code or protoc is needed.
+//
+// Decoding follows proto3 rules: fields may appear in any order, the last
+// value of a singular field wins, and unknown fields are skipped. On top of
+// that, every scalar and point must be canonical, and required fields must be
+// present.
+package frostpb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/soatok/frost"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Walk the fields of a message. The callback returns the number of bytes it
+// consumed, or -1 to skip a field it does not know.
+func parse(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n, err := field(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// Consume a length-delimited field.
+func consumeBytes(num protowire.Number, typ protowire.Type, b []byte) ([]byte, int, error) {
+	if typ != protowire.BytesType {
+		return nil, 0, fmt.Errorf("field %d has wire type %d, expected bytes", num, typ)
+	}
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return nil, 0, protowire.ParseError(n)
+	}
+	return v, n, nil
+}
+
+func appendBytes(out []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return out
+	}
+	out = protowire.AppendTag(out, num, protowire.BytesType)
+	return protowire.AppendBytes(out, v)
+}
+
+func scalar(b []byte) (*frost.Scalar, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("invalid scalar length: %d", len(b))
+	}
+	return frost.ScalarFromBytes(b)
+}
+
+func identifier(b []byte) (*frost.Scalar, error) {
+	s, err := scalar(b)
+	if err != nil {
+		return nil, err
+	}
+	if s.Equal(frost.NewScalar()) {
+		return nil, fmt.Errorf("zero identifier")
+	}
+	return s, nil
+}
+
+func element(b []byte) (*frost.Element, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("invalid point length: %d", len(b))
+	}
+	e, err := frost.ElementFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(e.Bytes(), b) {
+		return nil, fmt.Errorf("non-canonical point encoding")
+	}
+	return e, nil
+}
+
+// MarshalParticipant encodes a Participant message.
+func MarshalParticipant(p *frost.Participant) []byte {
+	var out []byte
+	out = appendBytes(out, 1, p.Identifier.Bytes())
+	return appendBytes(out, 2, p.PublicKeyShare.Bytes())
+}
+
+// UnmarshalParticipant decodes a Participant message.
+func UnmarshalParticipant(b []byte) (*frost.Participant, error) {
+	var id, pub []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		switch num {
+		case 1:
+			id, n, err = consumeBytes(num, typ, b)
+		case 2:
+			pub, n, err = consumeBytes(num, typ, b)
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := new(frost.Participant)
+	if p.Identifier, err = identifier(id); err != nil {
+		return nil, err
+	}
+	if p.PublicKeyShare, err = element(pub); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MarshalCommitment encodes a Commitment message.
+func MarshalCommitment(com *frost.Commitment) []byte {
+	var out []byte
+	out = appendBytes(out, 1, com.Identifier.Bytes())
+	out = appendBytes(out, 2, com.Hiding.Bytes())
+	return appendBytes(out, 3, com.Binding.Bytes())
+}
+
+// UnmarshalCommitment decodes a Commitment message. Identity points are
+// rejected.
+func UnmarshalCommitment(b []byte) (*frost.Commitment, error) {
+	var id, hiding, binding []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		switch num {
+		case 1:
+			id, n, err = consumeBytes(num, typ, b)
+		case 2:
+			hiding, n, err = consumeBytes(num, typ, b)
+		case 3:
+			binding, n, err = consumeBytes(num, typ, b)
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	com := new(frost.Commitment)
+	if com.Identifier, err = identifier(id); err != nil {
+		return nil, err
+	}
+	if com.Hiding, err = element(hiding); err != nil {
+		return nil, err
+	}
+	if com.Binding, err = element(binding); err != nil {
+		return nil, err
+	}
+	if com.Hiding.IsIdentity() || com.Binding.IsIdentity() {
+		return nil, fmt.Errorf("commitment contains the identity element")
+	}
+	return com, nil
+}
+
+// MarshalSigningPackage encodes a SigningPackage message.
+func MarshalSigningPackage(pkg *frost.SigningPackage) []byte {
+	var out []byte
+	out = appendBytes(out, 1, pkg.SessionID)
+	out = appendBytes(out, 2, pkg.Message)
+	for _, com := range pkg.Commitments {
+		out = protowire.AppendTag(out, 3, protowire.BytesType)
+		out = protowire.AppendBytes(out, MarshalCommitment(com))
+	}
+	// The randomizer is derived from the seed, so only one is encoded
+	if pkg.Randomizer != nil && pkg.RandomizerSeed == nil {
+		out = appendBytes(out, 4, pkg.Randomizer.Bytes())
+	}
+	for _, label := range pkg.DerivationPath {
+		// Repeated elements are kept even when empty
+		out = protowire.AppendTag(out, 5, protowire.BytesType)
+		out = protowire.AppendBytes(out, label)
+	}
+	if pkg.AdaptorPoint != nil {
+		out = appendBytes(out, 6, pkg.AdaptorPoint.Bytes())
+	}
+	if pkg.BlindChallenge != nil {
+		out = appendBytes(out, 7, pkg.BlindChallenge.Bytes())
+	}
+	if pkg.RandomizerSeed != nil {
+		out = appendBytes(out, 8, pkg.RandomizerSeed)
+	}
+	return out
+}
+
+// UnmarshalSigningPackage decodes a SigningPackage message.
+func UnmarshalSigningPackage(b []byte) (*frost.SigningPackage, error) {
+	pkg := new(frost.SigningPackage)
+	var randomizer, adaptorPoint, blindChallenge []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		var v []byte
+		switch num {
+		case 1:
+			v, n, err = consumeBytes(num, typ, b)
+			pkg.SessionID = append([]byte{}, v...)
+		case 2:
+			v, n, err = consumeBytes(num, typ, b)
+			pkg.Message = append([]byte{}, v...)
+		case 3:
+			v, n, err = consumeBytes(num, typ, b)
+			if err == nil {
+				var com *frost.Commitment
+				com, err = UnmarshalCommitment(v)
+				pkg.Commitments = append(pkg.Commitments, com)
+			}
+		case 4:
+			randomizer, n, err = consumeBytes(num, typ, b)
+		case 5:
+			v, n, err = consumeBytes(num, typ, b)
+			pkg.DerivationPath = append(pkg.DerivationPath, append([]byte{}, v...))
+		case 6:
+			adaptorPoint, n, err = consumeBytes(num, typ, b)
+		case 7:
+			blindChallenge, n, err = consumeBytes(num, typ, b)
+		case 8:
+			v, n, err = consumeBytes(num, typ, b)
+			pkg.RandomizerSeed = append([]byte{}, v...)
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pkg.SessionID) == 0 {
+		pkg.SessionID = nil
+	}
+	if randomizer != nil {
+		if pkg.Randomizer, err = scalar(randomizer); err != nil {
+			return nil, err
+		}
+	}
+	if adaptorPoint != nil {
+		if pkg.AdaptorPoint, err = element(adaptorPoint); err != nil {
+			return nil, err
+		}
+	}
+	if blindChallenge != nil {
+		if pkg.BlindChallenge, err = scalar(blindChallenge); err != nil {
+			return nil, err
+		}
+	}
+	if pkg.RandomizerSeed != nil {
+		if len(pkg.RandomizerSeed) != frost.RandomizerSeedSize {
+			return nil, fmt.Errorf("randomizer seed must be %d bytes", frost.RandomizerSeedSize)
+		}
+		if pkg.Randomizer != nil {
+			return nil, fmt.Errorf("signing package has both a randomizer and a seed")
+		}
+		pkg.Randomizer = frost.DeriveRandomizer(pkg.RandomizerSeed, pkg)
+	}
+	return pkg, nil
+}
+
+// MarshalSignatureShare encodes a SignatureShare message.
+func MarshalSignatureShare(share *frost.SignatureShare) []byte {
+	var out []byte
+	out = appendBytes(out, 1, share.Identifier.Bytes())
+	// A zero share is still 32 bytes, so it is never omitted
+	out = protowire.AppendTag(out, 2, protowire.BytesType)
+	return protowire.AppendBytes(out, share.Share.Bytes())
+}
+
+// UnmarshalSignatureShare decodes a SignatureShare message.
+func UnmarshalSignatureShare(b []byte) (*frost.SignatureShare, error) {
+	var id, s []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		switch num {
+		case 1:
+			id, n, err = consumeBytes(num, typ, b)
+		case 2:
+			s, n, err = consumeBytes(num, typ, b)
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	share := new(frost.SignatureShare)
+	if share.Identifier, err = identifier(id); err != nil {
+		return nil, err
+	}
+	if share.Share, err = scalar(s); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// MarshalSignature encodes a Signature message.
+func MarshalSignature(sig *frost.Signature) []byte {
+	var out []byte
+	out = appendBytes(out, 1, sig.R.Bytes())
+	out = protowire.AppendTag(out, 2, protowire.BytesType)
+	return protowire.AppendBytes(out, sig.Z.Bytes())
+}
+
+// UnmarshalSignature decodes a Signature message.
+func UnmarshalSignature(b []byte) (*frost.Signature, error) {
+	var r, z []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		switch num {
+		case 1:
+			r, n, err = consumeBytes(num, typ, b)
+		case 2:
+			z, n, err = consumeBytes(num, typ, b)
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return frost.SignatureFromBytes(append(append([]byte{}, r...), z...))
+}
+
+// KeyPackage is everything one participant needs to sign: its secret share,
+// the group key and every participant's public key share. The VSS commitment
+// is optional.
+type KeyPackage struct {
+	SecretShare   *frost.SecretShare
+	GroupKey      *frost.GroupKey
+	Participants  []*frost.Participant
+	VssCommitment []*frost.Element
+}
+
+// NewKeyPackage extracts the key package of the i-th participant from key
+// generation output.
+func NewKeyPackage(ko *frost.KeygenOutput, i int) *KeyPackage {
+	return &KeyPackage{
+		SecretShare:   ko.ParticipantPrivateKeys[i],
+		GroupKey:      ko.GroupPublicKey,
+		Participants:  ko.Participants,
+		VssCommitment: ko.VssCommitment,
+	}
+}
+
+// Marshal encodes a KeyPackage message. The output is secret.
+func (kp *KeyPackage) Marshal() []byte {
+	var out []byte
+	out = appendBytes(out, 1, kp.SecretShare.Identifier.Bytes())
+	out = protowire.AppendTag(out, 2, protowire.BytesType)
+	out = protowire.AppendBytes(out, kp.SecretShare.Scalar.Bytes())
+	out = appendBytes(out, 3, kp.GroupKey.Bytes())
+	for _, p := range kp.Participants {
+		out = protowire.AppendTag(out, 4, protowire.BytesType)
+		out = protowire.AppendBytes(out, MarshalParticipant(p))
+	}
+	for _, e := range kp.VssCommitment {
+		out = appendBytes(out, 5, e.Bytes())
+	}
+	return out
+}
+
+// UnmarshalKeyPackage decodes a KeyPackage message.
+func UnmarshalKeyPackage(b []byte) (*KeyPackage, error) {
+	kp := new(KeyPackage)
+	var id, secret, gk []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		var v []byte
+		switch num {
+		case 1:
+			id, n, err = consumeBytes(num, typ, b)
+		case 2:
+			secret, n, err = consumeBytes(num, typ, b)
+		case 3:
+			gk, n, err = consumeBytes(num, typ, b)
+		case 4:
+			v, n, err = consumeBytes(num, typ, b)
+			if err == nil {
+				var p *frost.Participant
+				p, err = UnmarshalParticipant(v)
+				kp.Participants = append(kp.Participants, p)
+			}
+		case 5:
+			v, n, err = consumeBytes(num, typ, b)
+			if err == nil {
+				var e *frost.Element
+				e, err = element(v)
+				kp.VssCommitment = append(kp.VssCommitment, e)
+			}
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	kp.SecretShare = new(frost.SecretShare)
+	if kp.SecretShare.Identifier, err = identifier(id); err != nil {
+		return nil, err
+	}
+	if kp.SecretShare.Scalar, err = scalar(secret); err != nil {
+		return nil, err
+	}
+	e, err := element(gk)
+	if err != nil {
+		return nil, err
+	}
+	if e.IsIdentity() {
+		return nil, fmt.Errorf("group key is the identity")
+	}
+	kp.GroupKey = &frost.GroupKey{Element: e}
+	return kp, nil
+}
+
+// DKGRound1 is the broadcast message of the first round of a Pedersen DKG.
+// This package has no DKG of its own; the type exists so that DKG
+// implementations can share the schema.
+type DKGRound1 struct {
+	Identifier *frost.Scalar
+	// Commitment is the participant's coefficient commitments
+	Commitment []*frost.Element
+	// ProofOfKnowledge proves knowledge of the constant term
+	ProofOfKnowledge *frost.Signature
+}
+
+// Marshal encodes a DKGRound1 message.
+func (m *DKGRound1) Marshal() []byte {
+	var out []byte
+	out = appendBytes(out, 1, m.Identifier.Bytes())
+	for _, e := range m.Commitment {
+		out = appendBytes(out, 2, e.Bytes())
+	}
+	return appendBytes(out, 3, m.ProofOfKnowledge.Bytes())
+}
+
+// UnmarshalDKGRound1 decodes a DKGRound1 message.
+func UnmarshalDKGRound1(b []byte) (*DKGRound1, error) {
+	m := new(DKGRound1)
+	var id, proof []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		var v []byte
+		switch num {
+		case 1:
+			id, n, err = consumeBytes(num, typ, b)
+		case 2:
+			v, n, err = consumeBytes(num, typ, b)
+			if err == nil {
+				var e *frost.Element
+				e, err = element(v)
+				m.Commitment = append(m.Commitment, e)
+			}
+		case 3:
+			proof, n, err = consumeBytes(num, typ, b)
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if m.Identifier, err = identifier(id); err != nil {
+		return nil, err
+	}
+	if len(m.Commitment) == 0 {
+		return nil, fmt.Errorf("empty DKG commitment")
+	}
+	if m.ProofOfKnowledge, err = frost.SignatureFromBytes(proof); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DKGRound2 is the private message of the second round of a Pedersen DKG.
+type DKGRound2 struct {
+	Identifier   *frost.Scalar
+	SigningShare *frost.Scalar
+}
+
+// Marshal encodes a DKGRound2 message. The output is secret.
+func (m *DKGRound2) Marshal() []byte {
+	var out []byte
+	out = appendBytes(out, 1, m.Identifier.Bytes())
+	out = protowire.AppendTag(out, 2, protowire.BytesType)
+	return protowire.AppendBytes(out, m.SigningShare.Bytes())
+}
+
+// UnmarshalDKGRound2 decodes a DKGRound2 message.
+func UnmarshalDKGRound2(b []byte) (*DKGRound2, error) {
+	var id, share []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		switch num {
+		case 1:
+			id, n, err = consumeBytes(num, typ, b)
+		case 2:
+			share, n, err = consumeBytes(num, typ, b)
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	m := new(DKGRound2)
+	if m.Identifier, err = identifier(id); err != nil {
+		return nil, err
+	}
+	if m.SigningShare, err = scalar(share); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MarshalMessage encodes a message envelope as an Envelope message.
+func MarshalMessage(m *frost.Message) ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	var out []byte
+	out = protowire.AppendTag(out, 1, protowire.VarintType)
+	out = protowire.AppendVarint(out, uint64(m.Type))
+	out = appendBytes(out, 2, m.SessionID)
+	if m.From != nil {
+		out = appendBytes(out, 3, m.From.Bytes())
+	}
+	if m.To != nil {
+		out = appendBytes(out, 4, m.To.Bytes())
+	}
+	var num protowire.Number
+	var payload []byte
+	switch m.Type {
+	case frost.MessageTypeCommitment:
+		num, payload = 5, MarshalCommitment(m.Commitment)
+	case frost.MessageTypeSigningPackage:
+		num, payload = 6, MarshalSigningPackage(m.SigningPackage)
+	case frost.MessageTypeSignatureShare:
+		num, payload = 7, MarshalSignatureShare(m.SignatureShare)
+	default:
+		num, payload = 8, m.Payload
+	}
+	out = protowire.AppendTag(out, num, protowire.BytesType)
+	return protowire.AppendBytes(out, payload), nil
+}
+
+// UnmarshalMessage decodes an Envelope message.
+func UnmarshalMessage(b []byte) (*frost.Message, error) {
+	m := new(frost.Message)
+	var payloadField protowire.Number
+	var payload, from, to []byte
+	err := parse(b, func(num protowire.Number, typ protowire.Type, b []byte) (n int, err error) {
+		var v []byte
+		switch num {
+		case 1:
+			if typ != protowire.VarintType {
+				return 0, fmt.Errorf("field 1 has wire type %d, expected varint", typ)
+			}
+			var t uint64
+			t, n = protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, protowire.ParseError(n)
+			}
+			m.Type = frost.MessageType(int32(t))
+		case 2:
+			v, n, err = consumeBytes(num, typ, b)
+			m.SessionID = append([]byte{}, v...)
+		case 3:
+			from, n, err = consumeBytes(num, typ, b)
+		case 4:
+			to, n, err = consumeBytes(num, typ, b)
+		case 5, 6, 7, 8:
+			// Members of a oneof: the last one wins
+			payloadField = num
+			payload, n, err = consumeBytes(num, typ, b)
+		default:
+			n = -1
+		}
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(m.SessionID) == 0 {
+		m.SessionID = nil
+	}
+	if from != nil {
+		if m.From, err = identifier(from); err != nil {
+			return nil, err
+		}
+	}
+	if to != nil {
+		if m.To, err = identifier(to); err != nil {
+			return nil, err
+		}
+	}
+
+	want := map[frost.MessageType]protowire.Number{
+		frost.MessageTypeKeyGen1:        8,
+		frost.MessageTypeKeyGen2:        8,
+		frost.MessageTypeCommitment:     5,
+		frost.MessageTypeSigningPackage: 6,
+		frost.MessageTypeSignatureShare: 7,
+	}[m.Type]
+	if want == 0 || payloadField != want {
+		return nil, fmt.Errorf("payload does not match message type %s", m.Type)
+	}
+	switch m.Type {
+	case frost.MessageTypeCommitment:
+		m.Commitment, err = UnmarshalCommitment(payload)
+	case frost.MessageTypeSigningPackage:
+		m.SigningPackage, err = UnmarshalSigningPackage(payload)
+	case frost.MessageTypeSignatureShare:
+		m.SignatureShare, err = UnmarshalSignatureShare(payload)
+	default:
+		m.Payload = append([]byte{}, payload...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -4,10 +4,15 @@ go 1.25.0
 
 require filippo.io/edwards25519 v1.1.0
 
-require github.com/stretchr/testify v1.11.0
+require (
+	github.com/stretchr/testify v1.11.0
+	golang.org/x/crypto v0.50.0
+	google.golang.org/protobuf v1.36.12
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"fmt"
+)
+
+// PreSignature is an aggregated adaptor signature that is still missing the
+// discrete log t of its adaptor point T: a pair (R + T, z') with
+// [z']B + T = (R + T) + [c]PK.
+type PreSignature struct {
+	// R is the nonce of the completed signature: the group commitment plus T
+	R *Element
+
+	// Z is the aggregated response, which is short by t
+	Z *Scalar
+
+	// T is the adaptor point
+	T *Element
+}
+
+// SetAdaptorPoint binds this State's signature to an adaptor point T, whose
+// discrete log the signers do not need to know: the challenge is computed over
+// R + T instead of the group commitment R. It must be called before Sign, and
+// the result must be aggregated with AggregatePreSignature.
+func (s *State) SetAdaptorPoint(t *Element) error {
+	if s.groupCommitment != nil {
+		return fmt.Errorf("adaptor point must be set before signing")
+	}
+	if t == nil || t.IsIdentity() {
+		return fmt.Errorf("invalid adaptor point")
+	}
+	if s.adaptorPoint != nil && !s.adaptorPoint.Equal(t) {
+		return fmt.Errorf("a different adaptor point is already set")
+	}
+	s.adaptorPoint = t
+	return nil
+}
+
+// AggregatePreSignature aggregates the signature shares of an adaptor
+// signature into a pre-signature.
+func (s *State) AggregatePreSignature(shares []*SignatureShare) (*PreSignature, error) {
+	if s.groupCommitment == nil {
+		return nil, fmt.Errorf("group commitment not computed")
+	}
+	if s.adaptorPoint == nil {
+		return nil, fmt.Errorf("no adaptor point set")
+	}
+	return &PreSignature{
+		R: s.signatureNonce(),
+		Z: s.aggregateResponse(shares),
+		T: s.adaptorPoint,
+	}, nil
+}
+
+// VerifyPreSignature checks [z']B + T = R + [c]PK, where R already includes T.
+func VerifyPreSignature(c Ciphersuite, groupKey *Element, msg []byte, pre *PreSignature) (bool, error) {
+	if groupKey == nil || pre == nil || pre.R == nil || pre.Z == nil || pre.T == nil {
+		return false, fmt.Errorf("missing pre-signature or group key")
+	}
+	challenge := ComputeChallenge(c, pre.R, groupKey, msg)
+	l := NewElement().Mul(pre.Z, nil)
+	l.Add(l, pre.T)
+	r := NewElement().Mul(challenge, groupKey)
+	r.Add(r, pre.R)
+	return NewElement().MultByCofactor(NewElement().Sub(l, r)).IsIdentity(), nil
+}
+
+// Complete turns a pre-signature into the ordinary Ed25519 signature
+// (R + T, z' + t), given the discrete log t of the adaptor point.
+func (pre *PreSignature) Complete(t *Scalar) (*Signature, error) {
+	if !NewElement().Mul(t, nil).Equal(pre.T) {
+		return nil, fmt.Errorf("scalar is not the discrete log of the adaptor point")
+	}
+	return &Signature{
+		R: pre.R,
+		Z: NewScalar().Add(pre.Z, t),
+	}, nil
+}
+
+// Extract recovers the discrete log of the adaptor point from a completed
+// signature, as t = z - z'.
+func (pre *PreSignature) Extract(sig *Signature) (*Scalar, error) {
+	if sig == nil || !sig.R.Equal(pre.R) {
+		return nil, fmt.Errorf("signature does not complete this pre-signature")
+	}
+	t := NewScalar().Sub(sig.Z, pre.Z)
+	if !NewElement().Mul(t, nil).Equal(pre.T) {
+		return nil, fmt.Errorf("signature does not complete this pre-signature")
+	}
+	return t, nil
+}
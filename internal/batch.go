@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"filippo.io/edwards25519"
+)
+
+// batchEntry is a single (group key, message, signature) triple queued for
+// batch verification.
+type batchEntry struct {
+	groupKey *GroupKey
+	msg      []byte
+	sig      *Signature
+}
+
+// BatchVerifier checks many signatures at once with a single
+// random-linear-combination multi-scalar multiplication.
+//
+// Batch verification is only sound with the cofactored equation, so every
+// signature is checked as if by Verify with VerifyCofactored.
+type BatchVerifier struct {
+	c       Ciphersuite
+	entries []batchEntry
+}
+
+// NewBatchVerifier creates an empty batch verifier.
+func NewBatchVerifier(c Ciphersuite) *BatchVerifier {
+	return &BatchVerifier{c: c}
+}
+
+// Add queues a signature for verification.
+func (bv *BatchVerifier) Add(groupKey *GroupKey, msg []byte, sig *Signature) {
+	bv.entries = append(bv.entries, batchEntry{groupKey: groupKey, msg: msg, sig: sig})
+}
+
+// Len returns the number of queued signatures.
+func (bv *BatchVerifier) Len() int {
+	return len(bv.entries)
+}
+
+// Verify checks every queued signature. If the batch equation fails, each
+// signature is verified individually, and the indices (in the order they
+// were added) of the invalid ones are returned.
+func (bv *BatchVerifier) Verify() (bool, []int, error) {
+	if len(bv.entries) == 0 {
+		return true, nil, nil
+	}
+	for i, entry := range bv.entries {
+		if entry.groupKey == nil || entry.groupKey.Element == nil || entry.sig == nil || entry.sig.R == nil || entry.sig.Z == nil {
+			return false, nil, fmt.Errorf("batch entry %d is incomplete", i)
+		}
+	}
+
+	ok, err := bv.verifyBatch()
+	if err != nil {
+		return false, nil, err
+	}
+	if ok {
+		return true, nil, nil
+	}
+
+	// Something in the batch is bad; find out what.
+	var invalid []int
+	for i, entry := range bv.entries {
+		valid, err := Verify(bv.c, entry.groupKey.Element, entry.msg, entry.sig, VerifyCofactored)
+		if err != nil {
+			return false, nil, err
+		}
+		if !valid {
+			invalid = append(invalid, i)
+		}
+	}
+	return len(invalid) == 0, invalid, nil
+}
+
+// Checks [8]( -[sum(r_i * z_i)]B + sum([r_i]R_i) + sum([r_i * c_i]PK_i) ) = 0
+// where each r_i is a random 128-bit scalar.
+func (bv *BatchVerifier) verifyBatch() (bool, error) {
+	n := len(bv.entries)
+	scalars := make([]*edwards25519.Scalar, 0, 2*n+1)
+	points := make([]*edwards25519.Point, 0, 2*n+1)
+
+	zSum := edwards25519.NewScalar()
+	for _, entry := range bv.entries {
+		r, err := randomBatchWeight()
+		if err != nil {
+			return false, err
+		}
+		challenge := ComputeChallenge(bv.c, entry.sig.R, entry.groupKey.Element, entry.msg)
+
+		zSum.MultiplyAdd(r, entry.sig.Z.s, zSum)
+		scalars = append(scalars, r, edwards25519.NewScalar().Multiply(r, challenge.s))
+		points = append(points, entry.sig.R.e, entry.groupKey.Element.e)
+	}
+	scalars = append(scalars, edwards25519.NewScalar().Negate(zSum))
+	points = append(points, edwards25519.NewGeneratorPoint())
+
+	sum := edwards25519.NewIdentityPoint().VarTimeMultiScalarMult(scalars, points)
+	sum.MultByCofactor(sum)
+	return sum.Equal(edwards25519.NewIdentityPoint()) == 1, nil
+}
+
+// A random 128-bit scalar, which is enough for a 2^-128 chance that an
+// invalid batch goes undetected.
+func randomBatchWeight() (*edwards25519.Scalar, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b[:16]); err != nil {
+		return nil, err
+	}
+	return edwards25519.NewScalar().SetCanonicalBytes(b)
+}
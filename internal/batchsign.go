@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"fmt"
+)
+
+// BatchState signs several messages in a single two-round ceremony. Each
+// message gets its own State and nonce, so the resulting signatures are
+// fully independent; only the network round trips are shared.
+type BatchState struct {
+	States []*State
+}
+
+// NewBatchState creates one State per message.
+func NewBatchState(c Ciphersuite, participants []*Participant, groupKey *GroupKey, msgs [][]byte, myIdentifier *Scalar, mySecretShare *SecretShare) *BatchState {
+	states := make([]*State, len(msgs))
+	for i, msg := range msgs {
+		states[i] = NewState(c, participants, groupKey, msg, myIdentifier, mySecretShare)
+	}
+	return &BatchState{States: states}
+}
+
+// Commit performs the first round for every message, returning one
+// commitment per message, in order.
+func (b *BatchState) Commit() ([]*Commitment, error) {
+	commitments := make([]*Commitment, len(b.States))
+	for i, s := range b.States {
+		c, err := s.Commit()
+		if err != nil {
+			return nil, err
+		}
+		commitments[i] = c
+	}
+	return commitments, nil
+}
+
+// SignPackages performs the second round for every message. Every package is
+// checked, and approved by each State's Policy, before any nonce is used, so a
+// single bad or refused package means no shares are released at all.
+func (b *BatchState) SignPackages(pkgs []*SigningPackage) ([]*SignatureShare, error) {
+	if len(pkgs) != len(b.States) {
+		return nil, fmt.Errorf("expected %d signing packages, got %d", len(b.States), len(pkgs))
+	}
+	for i, s := range b.States {
+		if err := s.checkPackage(pkgs[i]); err != nil {
+			return nil, fmt.Errorf("signing package %d: %w", i, err)
+		}
+	}
+	// Nothing is used up, and no State changes, until every package is approved
+	reservations := make([]*Reservation, 0, len(b.States))
+	for i, s := range b.States {
+		r, err := s.reservePackage(pkgs[i])
+		if err != nil {
+			for _, r := range reservations {
+				r.Cancel()
+			}
+			return nil, fmt.Errorf("signing package %d: %w", i, s.refused(err))
+		}
+		reservations = append(reservations, r)
+	}
+	for _, r := range reservations {
+		r.Commit()
+	}
+	for i, s := range b.States {
+		if err := s.applyPackage(pkgs[i]); err != nil {
+			return nil, err
+		}
+	}
+	shares := make([]*SignatureShare, len(b.States))
+	for i, s := range b.States {
+		share, err := s.sign(pkgs[i].Commitments)
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = share
+	}
+	return shares, nil
+}
+
+// BatchCoordinator runs one Coordinator per message, and keeps their signing
+// sets identical.
+type BatchCoordinator struct {
+	Coordinators []*Coordinator
+}
+
+// NewBatchCoordinator creates one Coordinator per message.
+func NewBatchCoordinator(c Ciphersuite, participants []*Participant, groupKey *GroupKey, threshold int, msgs [][]byte) (*BatchCoordinator, error) {
+	coordinators := make([]*Coordinator, len(msgs))
+	for i, msg := range msgs {
+		co, err := NewCoordinator(c, participants, groupKey, threshold, msg)
+		if err != nil {
+			return nil, err
+		}
+		coordinators[i] = co
+	}
+	return &BatchCoordinator{Coordinators: coordinators}, nil
+}
+
+// AddCommitments records one signer's commitments, one per message.
+func (b *BatchCoordinator) AddCommitments(commitments []*Commitment) error {
+	if len(commitments) != len(b.Coordinators) {
+		return fmt.Errorf("expected %d commitments, got %d", len(b.Coordinators), len(commitments))
+	}
+	// Check everything up front, so a signer is either added to every
+	// coordinator or to none of them.
+	for _, com := range commitments {
+		if err := ValidateCommitment(com); err != nil {
+			return err
+		}
+		if !com.Identifier.Equal(commitments[0].Identifier) {
+			return fmt.Errorf("commitments come from different signers")
+		}
+	}
+	for _, co := range b.Coordinators {
+		if co.pkg != nil {
+			return fmt.Errorf("signing set already chosen")
+		}
+		if co.commitment(commitments[0].Identifier) != nil {
+			return fmt.Errorf("duplicate commitment from participant")
+		}
+	}
+	for i, co := range b.Coordinators {
+		if err := co.AddCommitment(commitments[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SigningPackages chooses the signing set, which is the same for every
+// message, and returns one package per message.
+func (b *BatchCoordinator) SigningPackages(signers ...*Scalar) ([]*SigningPackage, error) {
+	pkgs := make([]*SigningPackage, len(b.Coordinators))
+	for i, co := range b.Coordinators {
+		pkg, err := co.SigningPackage(signers...)
+		if err != nil {
+			return nil, err
+		}
+		pkgs[i] = pkg
+	}
+	return pkgs, nil
+}
+
+// AddSignatureShares verifies one signer's shares, one per message. The error
+// of the first invalid share is returned, but valid shares are still kept.
+func (b *BatchCoordinator) AddSignatureShares(shares []*SignatureShare) error {
+	if len(shares) != len(b.Coordinators) {
+		return fmt.Errorf("expected %d signature shares, got %d", len(b.Coordinators), len(shares))
+	}
+	var first error
+	for i, co := range b.Coordinators {
+		if err := co.AddSignatureShare(shares[i]); err != nil && first == nil {
+			first = fmt.Errorf("signature share %d: %w", i, err)
+		}
+	}
+	return first
+}
+
+// Aggregate produces one signature per message, in order.
+func (b *BatchCoordinator) Aggregate() ([]*Signature, error) {
+	sigs := make([]*Signature, len(b.Coordinators))
+	for i, co := range b.Coordinators {
+		sig, err := co.Aggregate()
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		sigs[i] = sig
+	}
+	return sigs, nil
+}
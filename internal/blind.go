@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrTooManyBlindSessions is returned when a BlindSigner already has as many
+// sessions open as it allows.
+var ErrTooManyBlindSessions = errors.New("too many blind signing sessions open")
+
+// BlindRequest is the requester's side of a blind signing session. The
+// signers commit as usual, but never see the message: the requester takes
+// their group commitment R, picks random blinding scalars alpha and beta, and
+// computes
+//
+//	R' = R + [alpha]B + [beta]PK
+//	c' = H2(R' || PK || msg)
+//	c  = c' + beta
+//
+// The signers produce their shares for the blinded challenge c, which the
+// coordinator verifies and aggregates into (R, z) as usual. The requester
+// unblinds that into (R', z + alpha), an ordinary Ed25519 signature on msg
+// that is statistically independent of everything the signers saw.
+type BlindRequest struct {
+	Ciphersuite Ciphersuite
+	GroupKey    *GroupKey
+	Message     []byte
+
+	alpha           *Scalar
+	beta            *Scalar
+	groupCommitment *Element
+	blindedNonce    *Element
+	challenge       *Scalar
+}
+
+// NewBlindRequest draws fresh blinding factors from r. A nil reader means
+// crypto/rand.
+func NewBlindRequest(c Ciphersuite, groupKey *GroupKey, msg []byte, r io.Reader) (*BlindRequest, error) {
+	alpha, err := RandomScalarFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	beta, err := RandomScalarFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindRequest{
+		Ciphersuite: c,
+		GroupKey:    groupKey,
+		Message:     msg,
+		alpha:       alpha,
+		beta:        beta,
+	}, nil
+}
+
+// Blind computes the blinded challenge for the signers' commitments. The
+// binding factors are computed over an empty message, since the signers must
+// be able to compute them, so the coordinator's State for a blind session
+// should be created with an empty message.
+func (b *BlindRequest) Blind(commitments []*Commitment) (*Scalar, error) {
+	if b.groupCommitment != nil {
+		return nil, fmt.Errorf("challenge already blinded")
+	}
+	commitments = append([]*Commitment{}, commitments...)
+	bindingFactors := ComputeBindingFactors(b.Ciphersuite, b.GroupKey.Element, commitments, nil)
+	groupCommitment, err := ComputeGroupCommitment(commitments, bindingFactors)
+	if err != nil {
+		return nil, err
+	}
+	// R' = R + [alpha]B + [beta]PK
+	blindedNonce := NewElement().Add(groupCommitment, NewElement().Mul(b.alpha, nil))
+	blindedNonce.Add(blindedNonce, NewElement().Mul(b.beta, b.GroupKey.Element))
+
+	// c = H2(R' || PK || msg) + beta
+	challenge := ComputeChallenge(b.Ciphersuite, blindedNonce, b.GroupKey.Element, b.Message)
+	challenge.Add(challenge, b.beta)
+
+	b.groupCommitment = groupCommitment
+	b.blindedNonce = blindedNonce
+	b.challenge = challenge
+	return challenge, nil
+}
+
+// Unblind turns the aggregated signature over the blinded challenge into an
+// ordinary signature on the requester's message.
+func (b *BlindRequest) Unblind(sig *Signature) (*Signature, error) {
+	if b.groupCommitment == nil {
+		return nil, fmt.Errorf("challenge not blinded yet")
+	}
+	if sig == nil || !sig.R.Equal(b.groupCommitment) {
+		return nil, fmt.Errorf("signature is for a different group commitment")
+	}
+	// zB = R + [c]PK
+	l := NewElement().Mul(sig.Z, nil)
+	r := NewElement().Add(b.groupCommitment, NewElement().Mul(b.challenge, b.GroupKey.Element))
+	if !l.Equal(r) {
+		return nil, fmt.Errorf("blinded signature is invalid")
+	}
+	return &Signature{
+		R: b.blindedNonce,
+		Z: NewScalar().Add(sig.Z, b.alpha),
+	}, nil
+}
+
+// BlindSigner is a signer that has opted in to blind signing. It creates the
+// States for its blind sessions, and counts those still open. Only a State
+// from a BlindSigner accepts a blinded challenge, since signing one means
+// signing a message the signer cannot see.
+//
+// Blind Schnorr signatures are vulnerable to the ROS attack when a signer runs
+// many sessions concurrently, so a BlindSigner refuses to open more than
+// MaxSessions at once. Only a MaxSessions of 1 is safe.
+type BlindSigner struct {
+	Ciphersuite   Ciphersuite
+	Participants  []*Participant
+	GroupKey      *GroupKey
+	MyIdentifier  *Scalar
+	MySecretShare *SecretShare
+	// MaxSessions is the most blind sessions that may be open at once.
+	// Values above 1 are unsafe: concurrent sessions are exactly what the
+	// ROS attack needs to forge signatures.
+	MaxSessions int
+
+	mu   sync.Mutex
+	open int
+}
+
+// NewBlindSigner creates a blind signer that allows at most maxSessions
+// sessions to be open at once. Use 1: any higher value is open to the ROS
+// attack.
+func NewBlindSigner(c Ciphersuite, participants []*Participant, groupKey *GroupKey, myIdentifier *Scalar, mySecretShare *SecretShare, maxSessions int) (*BlindSigner, error) {
+	if mySecretShare == nil {
+		return nil, fmt.Errorf("a blind signer needs a secret share")
+	}
+	if maxSessions < 1 {
+		return nil, fmt.Errorf("a blind signer must allow at least one session")
+	}
+	return &BlindSigner{
+		Ciphersuite:   c,
+		Participants:  participants,
+		GroupKey:      groupKey,
+		MyIdentifier:  myIdentifier,
+		MySecretShare: mySecretShare,
+		MaxSessions:   maxSessions,
+	}, nil
+}
+
+// NewState opens a blind session. The session stays open until its State
+// signs, is closed, or has a request refused by its Policy.
+func (b *BlindSigner) NewState() (*State, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.open >= b.MaxSessions {
+		return nil, ErrTooManyBlindSessions
+	}
+	b.open++
+	s := NewState(b.Ciphersuite, b.Participants, b.GroupKey, nil, b.MyIdentifier, b.MySecretShare)
+	s.blind = b
+	return s, nil
+}
+
+// OpenSessions returns the number of blind sessions currently open.
+func (b *BlindSigner) OpenSessions() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// Close the State's blind session, if it has one still open.
+func (s *State) endBlindSession() {
+	if s.blind == nil || s.blindClosed {
+		return
+	}
+	s.blindClosed = true
+	s.blind.mu.Lock()
+	s.blind.open--
+	s.blind.mu.Unlock()
+}
+
+// SetBlindChallenge makes this State use a requester's blinded challenge
+// instead of computing its own. It must be called before Sign, and a signer's
+// State must come from a BlindSigner.
+func (s *State) SetBlindChallenge(c *Scalar) error {
+	if s.MySecretShare != nil && s.blind == nil {
+		return fmt.Errorf("signer has not opted in to blind signing")
+	}
+	if s.groupCommitment != nil {
+		return fmt.Errorf("blind challenge must be set before signing")
+	}
+	if c == nil {
+		return fmt.Errorf("missing blind challenge")
+	}
+	if s.blindChallenge != nil && !s.blindChallenge.Equal(c) {
+		return fmt.Errorf("a different blind challenge is already set")
+	}
+	s.blindChallenge = c
+	return nil
+}
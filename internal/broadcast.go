@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// CulpritError is returned by BroadcastSigner.Aggregate when some signature
+// shares fail verification. Culprits are sorted by identifier, so every
+// honest signer that saw the same broadcasts reports the same list.
+type CulpritError struct {
+	Culprits []*Scalar
+}
+
+func (e *CulpritError) Error() string {
+	ids := make([]string, len(e.Culprits))
+	for i, id := range e.Culprits {
+		ids[i] = fmt.Sprintf("%x", id.Bytes())
+	}
+	return fmt.Sprintf("%s from participants %s", ErrInvalidShare, strings.Join(ids, ", "))
+}
+
+func (e *CulpritError) Is(target error) bool {
+	return target == ErrInvalidShare
+}
+
+// BroadcastSigner runs a signing ceremony without a coordinator. Every signer
+// in an agreed signing set broadcasts its commitment, then its signature
+// share, and each one verifies every share and aggregates on its own.
+//
+// All honest signers end with the same signature, or the same culprits, as
+// long as the broadcast channel delivers the same messages to everyone.
+// Equivocation (different messages to different peers) must be prevented by
+// the channel itself, e.g. with an echo round.
+type BroadcastSigner struct {
+	state       *State
+	signers     []*Scalar
+	commitments []*Commitment
+	shares      []*SignatureShare
+	signature   *Signature
+}
+
+// NewBroadcastSigner prepares a signer for a ceremony among the given signing
+// set, which must include the State's own identifier and at least threshold
+// signers.
+func NewBroadcastSigner(state *State, threshold int, signers ...*Scalar) (*BroadcastSigner, error) {
+	if state.MySecretShare == nil {
+		return nil, fmt.Errorf("broadcast signing requires a secret share")
+	}
+	if err := CheckThreshold(threshold, len(state.Participants)); err != nil {
+		return nil, err
+	}
+	if len(signers) < threshold {
+		return nil, fmt.Errorf("signing set has %d signers, threshold is %d", len(signers), threshold)
+	}
+	set := make([]*Scalar, 0, len(signers))
+	for _, id := range signers {
+		found := false
+		for _, p := range state.Participants {
+			if p.Identifier.Equal(id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("signer %x is not a participant", id.Bytes())
+		}
+		for _, s := range set {
+			if s.Equal(id) {
+				return nil, fmt.Errorf("signer %x listed twice", id.Bytes())
+			}
+		}
+		set = append(set, id)
+	}
+	if !slices.ContainsFunc(set, state.MyIdentifier.Equal) {
+		return nil, fmt.Errorf("signing set does not include this signer")
+	}
+	sortIdentifiers(set)
+	return &BroadcastSigner{state: state, signers: set}, nil
+}
+
+// State returns the underlying State.
+func (b *BroadcastSigner) State() *State {
+	return b.state
+}
+
+// Commit produces this signer's commitment, to be broadcast to every peer.
+func (b *BroadcastSigner) Commit() (*Commitment, error) {
+	if b.commitment(b.state.MyIdentifier) != nil {
+		return nil, fmt.Errorf("already committed")
+	}
+	com, err := b.state.Commit()
+	if err != nil {
+		return nil, err
+	}
+	b.commitments = append(b.commitments, com)
+	return com, nil
+}
+
+// AddCommitment records a peer's broadcast commitment. A repeated broadcast of
+// the same commitment is ignored; a different one is an error.
+func (b *BroadcastSigner) AddCommitment(com *Commitment) error {
+	if err := ValidateCommitment(com); err != nil {
+		return err
+	}
+	if !b.inSet(com.Identifier) {
+		return fmt.Errorf("commitment from a participant outside the signing set")
+	}
+	if prev := b.commitment(com.Identifier); prev != nil {
+		if prev.Hiding.Equal(com.Hiding) && prev.Binding.Equal(com.Binding) {
+			return nil
+		}
+		return fmt.Errorf("conflicting commitments from participant %x", com.Identifier.Bytes())
+	}
+	b.commitments = append(b.commitments, com)
+	return nil
+}
+
+// Sign produces this signer's share, to be broadcast to every peer, once a
+// commitment has been received from every signer.
+func (b *BroadcastSigner) Sign() (*SignatureShare, error) {
+	if missing := len(b.MissingCommitments()); missing > 0 {
+		return nil, fmt.Errorf("%d commitments missing", missing)
+	}
+	// Every signer must build the same commitment list
+	sort.Slice(b.commitments, func(i, j int) bool {
+		return bytes.Compare(b.commitments[i].Identifier.Bytes(), b.commitments[j].Identifier.Bytes()) < 0
+	})
+	share, err := b.state.Sign(b.commitments)
+	if err != nil {
+		return nil, err
+	}
+	b.shares = append(b.shares, share)
+	return share, nil
+}
+
+// AddSignatureShare records a peer's broadcast share. Shares are only
+// verified in Aggregate, so that every signer judges the complete set.
+func (b *BroadcastSigner) AddSignatureShare(share *SignatureShare) error {
+	if share == nil || share.Identifier == nil || share.Share == nil {
+		return fmt.Errorf("incomplete signature share")
+	}
+	if !b.inSet(share.Identifier) {
+		return fmt.Errorf("share from a participant outside the signing set")
+	}
+	for _, s := range b.shares {
+		if s.Identifier.Equal(share.Identifier) {
+			if s.Share.Equal(share.Share) {
+				return nil
+			}
+			return fmt.Errorf("conflicting signature shares from participant %x", share.Identifier.Bytes())
+		}
+	}
+	b.shares = append(b.shares, share)
+	return nil
+}
+
+// MissingCommitments returns the signers that have not broadcast a
+// commitment yet.
+func (b *BroadcastSigner) MissingCommitments() []*Scalar {
+	var missing []*Scalar
+	for _, id := range b.signers {
+		if b.commitment(id) == nil {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// MissingShares returns the signers that have not broadcast a signature
+// share yet.
+func (b *BroadcastSigner) MissingShares() []*Scalar {
+	var missing []*Scalar
+	for _, id := range b.signers {
+		if !slices.ContainsFunc(b.shares, func(s *SignatureShare) bool { return s.Identifier.Equal(id) }) {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// Aggregate verifies every share and produces the signature. If any share is
+// invalid it returns a *CulpritError naming every signer whose share failed.
+func (b *BroadcastSigner) Aggregate() (*Signature, error) {
+	if b.signature != nil {
+		return b.signature, nil
+	}
+	if b.state.groupCommitment == nil {
+		return nil, fmt.Errorf("this signer has not signed yet")
+	}
+	if missing := len(b.MissingShares()); missing > 0 {
+		return nil, fmt.Errorf("%d signature shares missing", missing)
+	}
+	var culprits []*Scalar
+	for _, share := range b.shares {
+		ok, err := b.state.VerifySignatureShare(share)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			culprits = append(culprits, share.Identifier)
+		}
+	}
+	if len(culprits) > 0 {
+		sortIdentifiers(culprits)
+		return nil, &CulpritError{Culprits: culprits}
+	}
+	sig, err := b.state.Aggregate(b.shares)
+	if err != nil {
+		return nil, err
+	}
+	// Valid shares from too small a signing set still add up to an invalid
+	// signature, so check the result before keeping it
+	ok, err := b.verify(sig)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("aggregate signature does not verify under the group key")
+	}
+	b.signature = sig
+	return sig, nil
+}
+
+// Verify the aggregate signature under the State's effective group key. A
+// blind signature only verifies against the blinded challenge.
+func (b *BroadcastSigner) verify(sig *Signature) (bool, error) {
+	if b.state.blindChallenge != nil {
+		l := NewElement().Mul(sig.Z, nil)
+		r := NewElement().Add(sig.R, NewElement().Mul(b.state.blindChallenge, b.state.GroupKey.Element))
+		return l.Equal(r), nil
+	}
+	return Verify(b.state.Ciphersuite, b.state.effectiveGroupKey(), b.state.Message, sig, VerifyCofactored)
+}
+
+func (b *BroadcastSigner) inSet(id *Scalar) bool {
+	return slices.ContainsFunc(b.signers, id.Equal)
+}
+
+func (b *BroadcastSigner) commitment(id *Scalar) *Commitment {
+	for _, c := range b.commitments {
+		if c.Identifier.Equal(id) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Sort identifiers the same way commitment lists are sorted.
+func sortIdentifiers(ids []*Scalar) {
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i].Bytes(), ids[j].Bytes()) < 0
+	})
+}
@@ -0,0 +1,688 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// CBOR major types. Fixed structures are encoded as arrays, and structures
+// with optional fields as maps with small integer keys; see frost.cddl.
+const (
+	cborUint  = 0
+	cborBytes = 2
+	cborArray = 4
+	cborMap   = 5
+)
+
+// Append a head with the shortest encoding of n.
+func appendCBORHead(out []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(out, major|byte(n))
+	case n <= 0xff:
+		return append(out, major|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(out, major|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(out, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(out, major|27), n)
+	}
+}
+
+func appendCBORBytes(out, b []byte) []byte {
+	return append(appendCBORHead(out, cborBytes, uint64(len(b))), b...)
+}
+
+// cborReader decodes deterministic CBOR. The first error sticks.
+type cborReader struct {
+	b   []byte
+	err error
+}
+
+func (r *cborReader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("cbor: "+format, args...)
+	}
+}
+
+// Read a head of the expected major type, rejecting indefinite lengths and
+// non-shortest arguments.
+func (r *cborReader) head(major byte) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.b) == 0 {
+		r.fail("unexpected end of input")
+		return 0
+	}
+	if r.b[0]>>5 != major {
+		r.fail("expected major type %d, got %d", major, r.b[0]>>5)
+		return 0
+	}
+	info := r.b[0] & 0x1f
+	r.b = r.b[1:]
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info)
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		r.fail("indefinite or reserved length")
+		return 0
+	}
+	if len(r.b) < size {
+		r.fail("unexpected end of input")
+		return 0
+	}
+	var n uint64
+	for _, c := range r.b[:size] {
+		n = n<<8 | uint64(c)
+	}
+	r.b = r.b[size:]
+	if len(appendCBORHead(nil, major, n)) != 1+size {
+		r.fail("non-shortest integer encoding")
+		return 0
+	}
+	return n
+}
+
+func (r *cborReader) uint() uint64 {
+	return r.head(cborUint)
+}
+
+func (r *cborReader) bytes() []byte {
+	n := r.head(cborBytes)
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.b)) {
+		r.fail("byte string exceeds input")
+		return nil
+	}
+	out := r.b[:n]
+	r.b = r.b[n:]
+	return out
+}
+
+// Read an array header of exactly n items.
+func (r *cborReader) array(n int) {
+	if got := r.head(cborArray); r.err == nil && got != uint64(n) {
+		r.fail("expected array of %d items, got %d", n, got)
+	}
+}
+
+// Read an array header of any length. Every item takes at least minSize
+// bytes, which bounds the length by the input.
+func (r *cborReader) arrayLen(minSize int) int {
+	n := r.head(cborArray)
+	if r.err == nil && n > uint64(len(r.b))/uint64(minSize) {
+		r.fail("array length exceeds input")
+		return 0
+	}
+	return int(n)
+}
+
+// Read the keys of a map with small integer keys, calling field for each in
+// turn. Keys must be strictly ascending, and known to the caller.
+func (r *cborReader) fields(known func(key uint64) bool, field func(key uint64)) {
+	n := r.head(cborMap)
+	if r.err == nil && n*2 > uint64(len(r.b)) {
+		r.fail("map length exceeds input")
+		return
+	}
+	var last uint64
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		key := r.uint()
+		switch {
+		case r.err != nil:
+			return
+		case i > 0 && key <= last:
+			r.fail("map keys out of order")
+			return
+		case !known(key):
+			r.fail("unknown map key %d", key)
+			return
+		}
+		last = key
+		field(key)
+	}
+}
+
+func (r *cborReader) scalar() *Scalar {
+	br := &byteReader{b: r.bytes()}
+	if r.err != nil {
+		return nil
+	}
+	s := br.scalar()
+	if br.err != nil || len(br.b) != 0 {
+		r.fail("malformed scalar")
+	}
+	return s
+}
+
+func (r *cborReader) identifier() *Scalar {
+	s := r.scalar()
+	if s != nil && s.Equal(NewScalar()) {
+		r.fail("zero identifier")
+		return nil
+	}
+	return s
+}
+
+func (r *cborReader) element() *Element {
+	br := &byteReader{b: r.bytes()}
+	if r.err != nil {
+		return nil
+	}
+	e := br.element()
+	if br.err != nil || len(br.b) != 0 {
+		r.fail("malformed element")
+	}
+	return e
+}
+
+// Fail unless the whole input was consumed.
+func (r *cborReader) done() error {
+	if r.err == nil && len(r.b) != 0 {
+		r.fail("trailing bytes")
+	}
+	return r.err
+}
+
+// EncodeCBOR encodes a scalar as a 32-byte string.
+func (s *Scalar) EncodeCBOR() []byte {
+	return appendCBORBytes(nil, s.Bytes())
+}
+
+// ScalarFromCBOR decodes a scalar.
+func ScalarFromCBOR(b []byte) (*Scalar, error) {
+	r := &cborReader{b: b}
+	s := r.scalar()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// EncodeCBOR encodes a point as a 32-byte string.
+func (e *Element) EncodeCBOR() []byte {
+	return appendCBORBytes(nil, e.Bytes())
+}
+
+// ElementFromCBOR decodes a point.
+func ElementFromCBOR(b []byte) (*Element, error) {
+	r := &cborReader{b: b}
+	e := r.element()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// EncodeCBOR encodes a group key as a 32-byte string.
+func (gk *GroupKey) EncodeCBOR() []byte {
+	return gk.Element.EncodeCBOR()
+}
+
+// GroupKeyFromCBOR decodes a group key. The identity is rejected.
+func GroupKeyFromCBOR(b []byte) (*GroupKey, error) {
+	e, err := ElementFromCBOR(b)
+	if err != nil {
+		return nil, err
+	}
+	if e.IsIdentity() {
+		return nil, fmt.Errorf("group key is the identity")
+	}
+	return &GroupKey{Element: e}, nil
+}
+
+// EncodeCBOR encodes a participant as [identifier, public key share].
+func (p *Participant) EncodeCBOR() []byte {
+	return p.appendCBOR(nil)
+}
+
+func (p *Participant) appendCBOR(out []byte) []byte {
+	out = appendCBORHead(out, cborArray, 2)
+	out = appendCBORBytes(out, p.Identifier.Bytes())
+	return appendCBORBytes(out, p.PublicKeyShare.Bytes())
+}
+
+func (r *cborReader) participant() *Participant {
+	r.array(2)
+	return &Participant{Identifier: r.identifier(), PublicKeyShare: r.element()}
+}
+
+// ParticipantFromCBOR decodes a participant.
+func ParticipantFromCBOR(b []byte) (*Participant, error) {
+	r := &cborReader{b: b}
+	p := r.participant()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// EncodeCBOR encodes a secret share as [identifier, secret]. The output is
+// secret.
+func (ss *SecretShare) EncodeCBOR() []byte {
+	return ss.appendCBOR(nil)
+}
+
+func (ss *SecretShare) appendCBOR(out []byte) []byte {
+	out = appendCBORHead(out, cborArray, 2)
+	out = appendCBORBytes(out, ss.Identifier.Bytes())
+	return appendCBORBytes(out, ss.Scalar.Bytes())
+}
+
+func (r *cborReader) secretShare() *SecretShare {
+	r.array(2)
+	return &SecretShare{Identifier: r.identifier(), Scalar: r.scalar()}
+}
+
+// SecretShareFromCBOR decodes a secret share.
+func SecretShareFromCBOR(b []byte) (*SecretShare, error) {
+	r := &cborReader{b: b}
+	ss := r.secretShare()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// EncodeCBOR encodes a commitment as [identifier, hiding, binding].
+func (com *Commitment) EncodeCBOR() []byte {
+	return com.appendCBOR(nil)
+}
+
+func (com *Commitment) appendCBOR(out []byte) []byte {
+	out = appendCBORHead(out, cborArray, 3)
+	out = appendCBORBytes(out, com.Identifier.Bytes())
+	out = appendCBORBytes(out, com.Hiding.Bytes())
+	return appendCBORBytes(out, com.Binding.Bytes())
+}
+
+func (r *cborReader) commitment() *Commitment {
+	r.array(3)
+	com := &Commitment{Identifier: r.identifier(), Hiding: r.element(), Binding: r.element()}
+	if r.err == nil {
+		if err := ValidateCommitment(com); err != nil {
+			r.err = err
+		}
+	}
+	return com
+}
+
+// CommitmentFromCBOR decodes a commitment. Identity elements are rejected.
+func CommitmentFromCBOR(b []byte) (*Commitment, error) {
+	r := &cborReader{b: b}
+	com := r.commitment()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return com, nil
+}
+
+// EncodeCBOR encodes a signature share as [identifier, share].
+func (sigshare *SignatureShare) EncodeCBOR() []byte {
+	out := appendCBORHead(nil, cborArray, 2)
+	out = appendCBORBytes(out, sigshare.Identifier.Bytes())
+	return appendCBORBytes(out, sigshare.Share.Bytes())
+}
+
+func (r *cborReader) signatureShare() *SignatureShare {
+	r.array(2)
+	return &SignatureShare{Identifier: r.identifier(), Share: r.scalar()}
+}
+
+// SignatureShareFromCBOR decodes a signature share.
+func SignatureShareFromCBOR(b []byte) (*SignatureShare, error) {
+	r := &cborReader{b: b}
+	share := r.signatureShare()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// EncodeCBOR encodes a signature as [R, z].
+func (s *Signature) EncodeCBOR() []byte {
+	out := appendCBORHead(nil, cborArray, 2)
+	out = appendCBORBytes(out, s.R.Bytes())
+	return appendCBORBytes(out, s.Z.Bytes())
+}
+
+// SignatureFromCBOR decodes a signature.
+func SignatureFromCBOR(b []byte) (*Signature, error) {
+	r := &cborReader{b: b}
+	r.array(2)
+	sig := &Signature{R: r.element(), Z: r.scalar()}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func appendCBORElements(out []byte, elements []*Element) []byte {
+	out = appendCBORHead(out, cborArray, uint64(len(elements)))
+	for _, e := range elements {
+		out = appendCBORBytes(out, e.Bytes())
+	}
+	return out
+}
+
+func (r *cborReader) elements() []*Element {
+	n := r.arrayLen(33)
+	out := make([]*Element, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		out = append(out, r.element())
+	}
+	return out
+}
+
+// EncodeVSSCommitmentCBOR encodes a VSS commitment as an array of points.
+func EncodeVSSCommitmentCBOR(vss []*Element) []byte {
+	return appendCBORElements(nil, vss)
+}
+
+// VSSCommitmentFromCBOR decodes a VSS commitment.
+func VSSCommitmentFromCBOR(b []byte) ([]*Element, error) {
+	r := &cborReader{b: b}
+	vss := r.elements()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if len(vss) == 0 {
+		return nil, fmt.Errorf("empty VSS commitment")
+	}
+	return vss, nil
+}
+
+// EncodeKeygenOutputCBOR encodes the result of key generation as
+// [secret shares, participants, group key, VSS commitment]. The output
+// contains every secret share.
+func EncodeKeygenOutputCBOR(shares []*SecretShare, participants []*Participant, groupKey *GroupKey, vss []*Element) []byte {
+	out := appendCBORHead(nil, cborArray, 4)
+	out = appendCBORHead(out, cborArray, uint64(len(shares)))
+	for _, ss := range shares {
+		out = ss.appendCBOR(out)
+	}
+	out = appendCBORHead(out, cborArray, uint64(len(participants)))
+	for _, p := range participants {
+		out = p.appendCBOR(out)
+	}
+	out = appendCBORBytes(out, groupKey.Bytes())
+	return appendCBORElements(out, vss)
+}
+
+// KeygenOutputFromCBOR decodes the result of key generation.
+func KeygenOutputFromCBOR(b []byte) ([]*SecretShare, []*Participant, *GroupKey, []*Element, error) {
+	r := &cborReader{b: b}
+	r.array(4)
+	n := r.arrayLen(67)
+	shares := make([]*SecretShare, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		shares = append(shares, r.secretShare())
+	}
+	n = r.arrayLen(67)
+	participants := make([]*Participant, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		participants = append(participants, r.participant())
+	}
+	gk := &GroupKey{Element: r.element()}
+	vss := r.elements()
+	if err := r.done(); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := CheckKeygenOutput(shares, participants, gk, vss); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return shares, participants, gk, vss, nil
+}
+
+// Map keys of a CBOR signing package.
+const (
+	cborPkgSessionID = iota + 1
+	cborPkgMessage
+	cborPkgCommitments
+	cborPkgRandomizer
+	cborPkgDerivationPath
+	cborPkgAdaptorPoint
+	cborPkgBlindChallenge
+	cborPkgRandomizerSeed
+)
+
+// EncodeCBOR encodes a signing package as a map. Commitments are sorted by
+// identifier, and optional fields are omitted when unset.
+func (pkg *SigningPackage) EncodeCBOR() []byte {
+	return pkg.appendCBOR(nil)
+}
+
+func (pkg *SigningPackage) appendCBOR(out []byte) []byte {
+	// The randomizer is derived from the seed, so only one is encoded
+	randomizer := pkg.Randomizer
+	if pkg.RandomizerSeed != nil {
+		randomizer = nil
+	}
+	fields := uint64(2)
+	for _, set := range []bool{len(pkg.SessionID) > 0, randomizer != nil, len(pkg.DerivationPath) > 0, pkg.AdaptorPoint != nil, pkg.BlindChallenge != nil, pkg.RandomizerSeed != nil} {
+		if set {
+			fields++
+		}
+	}
+	out = appendCBORHead(out, cborMap, fields)
+	if len(pkg.SessionID) > 0 {
+		out = appendCBORHead(out, cborUint, cborPkgSessionID)
+		out = appendCBORBytes(out, pkg.SessionID)
+	}
+	out = appendCBORHead(out, cborUint, cborPkgMessage)
+	out = appendCBORBytes(out, pkg.Message)
+	out = appendCBORHead(out, cborUint, cborPkgCommitments)
+	sorted := pkg.sortedCommitments()
+	out = appendCBORHead(out, cborArray, uint64(len(sorted)))
+	for _, c := range sorted {
+		out = c.appendCBOR(out)
+	}
+	if randomizer != nil {
+		out = appendCBORHead(out, cborUint, cborPkgRandomizer)
+		out = appendCBORBytes(out, randomizer.Bytes())
+	}
+	if len(pkg.DerivationPath) > 0 {
+		out = appendCBORHead(out, cborUint, cborPkgDerivationPath)
+		out = appendCBORHead(out, cborArray, uint64(len(pkg.DerivationPath)))
+		for _, label := range pkg.DerivationPath {
+			out = appendCBORBytes(out, label)
+		}
+	}
+	if pkg.AdaptorPoint != nil {
+		out = appendCBORHead(out, cborUint, cborPkgAdaptorPoint)
+		out = appendCBORBytes(out, pkg.AdaptorPoint.Bytes())
+	}
+	if pkg.BlindChallenge != nil {
+		out = appendCBORHead(out, cborUint, cborPkgBlindChallenge)
+		out = appendCBORBytes(out, pkg.BlindChallenge.Bytes())
+	}
+	if pkg.RandomizerSeed != nil {
+		out = appendCBORHead(out, cborUint, cborPkgRandomizerSeed)
+		out = appendCBORBytes(out, pkg.RandomizerSeed)
+	}
+	return out
+}
+
+func (r *cborReader) signingPackage() *SigningPackage {
+	pkg := new(SigningPackage)
+	var seen [cborPkgRandomizerSeed + 1]bool
+	r.fields(func(key uint64) bool {
+		return key >= cborPkgSessionID && key <= cborPkgRandomizerSeed
+	}, func(key uint64) {
+		seen[key] = true
+		switch key {
+		case cborPkgSessionID:
+			pkg.SessionID = append([]byte{}, r.bytes()...)
+			if r.err == nil && len(pkg.SessionID) == 0 {
+				r.fail("empty session ID")
+			}
+		case cborPkgMessage:
+			pkg.Message = append([]byte{}, r.bytes()...)
+		case cborPkgCommitments:
+			n := r.arrayLen(100)
+			for i := 0; i < n && r.err == nil; i++ {
+				pkg.Commitments = append(pkg.Commitments, r.commitment())
+			}
+		case cborPkgRandomizer:
+			pkg.Randomizer = r.scalar()
+		case cborPkgDerivationPath:
+			n := r.arrayLen(1)
+			for i := 0; i < n && r.err == nil; i++ {
+				pkg.DerivationPath = append(pkg.DerivationPath, append([]byte{}, r.bytes()...))
+			}
+			if r.err == nil && n == 0 {
+				r.fail("empty derivation path")
+			}
+		case cborPkgAdaptorPoint:
+			pkg.AdaptorPoint = r.element()
+		case cborPkgBlindChallenge:
+			pkg.BlindChallenge = r.scalar()
+		case cborPkgRandomizerSeed:
+			pkg.RandomizerSeed = append([]byte{}, r.bytes()...)
+		}
+	})
+	if r.err == nil && (!seen[cborPkgMessage] || !seen[cborPkgCommitments]) {
+		r.fail("signing package is missing required fields")
+	}
+	if r.err == nil {
+		// Commitments must already be in canonical order, without repeats
+		for i := 1; i < len(pkg.Commitments); i++ {
+			if bytes.Compare(pkg.Commitments[i-1].Identifier.Bytes(), pkg.Commitments[i].Identifier.Bytes()) >= 0 {
+				r.fail("commitments out of order or repeated")
+				break
+			}
+		}
+	}
+	if r.err == nil {
+		if err := pkg.deriveRandomizer(); err != nil {
+			r.fail("%w", err)
+		}
+	}
+	return pkg
+}
+
+// SigningPackageFromCBOR decodes a signing package.
+func SigningPackageFromCBOR(b []byte) (*SigningPackage, error) {
+	r := &cborReader{b: b}
+	pkg := r.signingPackage()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// Map keys of a CBOR message.
+const (
+	cborMsgType = iota + 1
+	cborMsgSessionID
+	cborMsgFrom
+	cborMsgTo
+	cborMsgPayload
+)
+
+// EncodeCBOR encodes a message envelope as a map. The payload is embedded
+// as CBOR, except for DKG rounds, whose opaque payload is a byte string.
+func (m *Message) EncodeCBOR() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	fields := uint64(2)
+	for _, set := range []bool{len(m.SessionID) > 0, m.From != nil, m.To != nil} {
+		if set {
+			fields++
+		}
+	}
+	out := appendCBORHead(nil, cborMap, fields)
+	out = appendCBORHead(out, cborUint, cborMsgType)
+	out = appendCBORHead(out, cborUint, uint64(m.Type))
+	if len(m.SessionID) > 0 {
+		out = appendCBORHead(out, cborUint, cborMsgSessionID)
+		out = appendCBORBytes(out, m.SessionID)
+	}
+	if m.From != nil {
+		out = appendCBORHead(out, cborUint, cborMsgFrom)
+		out = appendCBORBytes(out, m.From.Bytes())
+	}
+	if m.To != nil {
+		out = appendCBORHead(out, cborUint, cborMsgTo)
+		out = appendCBORBytes(out, m.To.Bytes())
+	}
+	out = appendCBORHead(out, cborUint, cborMsgPayload)
+	switch m.Type {
+	case MessageTypeCommitment:
+		out = m.Commitment.appendCBOR(out)
+	case MessageTypeSigningPackage:
+		out = m.SigningPackage.appendCBOR(out)
+	case MessageTypeSignatureShare:
+		out = append(out, m.SignatureShare.EncodeCBOR()...)
+	default:
+		out = appendCBORBytes(out, m.Payload)
+	}
+	return out, nil
+}
+
+// MessageFromCBOR decodes a message envelope and its payload.
+func MessageFromCBOR(b []byte) (*Message, error) {
+	r := &cborReader{b: b}
+	m := new(Message)
+	var typed, payload bool
+	r.fields(func(key uint64) bool {
+		return key >= cborMsgType && key <= cborMsgPayload
+	}, func(key uint64) {
+		switch key {
+		case cborMsgType:
+			typed = true
+			t := r.uint()
+			if t > 0xff {
+				r.fail("unknown message type %d", t)
+			}
+			m.Type = MessageType(t)
+		case cborMsgSessionID:
+			m.SessionID = append([]byte{}, r.bytes()...)
+			if r.err == nil && len(m.SessionID) == 0 {
+				r.fail("empty session ID")
+			}
+		case cborMsgFrom:
+			m.From = r.identifier()
+		case cborMsgTo:
+			m.To = r.identifier()
+		case cborMsgPayload:
+			payload = true
+			switch m.Type {
+			case MessageTypeCommitment:
+				m.Commitment = r.commitment()
+			case MessageTypeSigningPackage:
+				m.SigningPackage = r.signingPackage()
+			case MessageTypeSignatureShare:
+				m.SignatureShare = r.signatureShare()
+			default:
+				m.Payload = append([]byte{}, r.bytes()...)
+			}
+		}
+	})
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if !typed || !payload {
+		return nil, fmt.Errorf("cbor: message is missing required fields")
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,272 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidShare is returned when a signature share fails verification.
+var ErrInvalidShare = errors.New("invalid signature share")
+
+// Coordinator runs the coordinator's side of a signing ceremony. It never
+// holds a secret share.
+type Coordinator struct {
+	Ciphersuite  Ciphersuite
+	Participants []*Participant
+	GroupKey     *GroupKey
+	Threshold    int
+	Message      []byte
+	// SessionID is copied into the signing package, if set
+	SessionID []byte
+	// RandomizerSeed makes the ceremony produce a re-randomized signature
+	RandomizerSeed []byte
+	// Randomizer is derived from RandomizerSeed and the signing package, once
+	// SigningPackage has been called
+	Randomizer *Scalar
+	// DerivationPath makes the ceremony sign for a child key
+	DerivationPath [][]byte
+	// AdaptorPoint makes the ceremony produce a pre-signature
+	AdaptorPoint *Element
+	// BlindChallenge is a requester's blinded challenge, for blind signing
+	BlindChallenge *Scalar
+
+	// Every commitment received, in arrival order
+	received []*Commitment
+	// The chosen signing set, once SigningPackage has been called
+	pkg    *SigningPackage
+	state  *State
+	shares []*SignatureShare
+}
+
+// NewCoordinator creates a coordinator for signing msg with at least
+// threshold participants. The threshold must be between 1 and the number of
+// participants.
+func NewCoordinator(c Ciphersuite, participants []*Participant, groupKey *GroupKey, threshold int, msg []byte) (*Coordinator, error) {
+	if err := CheckThreshold(threshold, len(participants)); err != nil {
+		return nil, err
+	}
+	return &Coordinator{
+		Ciphersuite:  c,
+		Participants: participants,
+		GroupKey:     groupKey,
+		Threshold:    threshold,
+		Message:      msg,
+	}, nil
+}
+
+// CheckThreshold rejects a threshold no signing set of the participants can
+// meet.
+func CheckThreshold(threshold, participants int) error {
+	if threshold < 1 || threshold > participants {
+		return fmt.Errorf("threshold %d is out of range for %d participants", threshold, participants)
+	}
+	return nil
+}
+
+// AddCommitment records a first-round commitment from a participant.
+func (co *Coordinator) AddCommitment(com *Commitment) error {
+	if co.pkg != nil {
+		return fmt.Errorf("signing set already chosen")
+	}
+	if err := ValidateCommitment(com); err != nil {
+		return err
+	}
+	if co.participant(com.Identifier) == nil {
+		return fmt.Errorf("participant not found")
+	}
+	for _, c := range co.received {
+		if c.Identifier.Equal(com.Identifier) {
+			return fmt.Errorf("duplicate commitment from participant")
+		}
+	}
+	co.received = append(co.received, com)
+	return nil
+}
+
+// Commitments returns every commitment received so far.
+func (co *Coordinator) Commitments() []*Commitment {
+	return append([]*Commitment{}, co.received...)
+}
+
+// SigningPackage chooses the signing set and returns the package for round
+// two. With no identifiers, the first Threshold commitments to arrive are
+// used; otherwise, the listed participants are.
+//
+// Once chosen, the signing set is fixed and repeated calls return the same
+// package.
+func (co *Coordinator) SigningPackage(signers ...*Scalar) (*SigningPackage, error) {
+	if co.pkg != nil {
+		return co.pkg, nil
+	}
+	var chosen []*Commitment
+	if len(signers) == 0 {
+		if len(co.received) < co.Threshold {
+			return nil, fmt.Errorf("only %d of %d commitments received", len(co.received), co.Threshold)
+		}
+		chosen = append(chosen, co.received[:co.Threshold]...)
+	} else {
+		for _, id := range signers {
+			com := co.commitment(id)
+			if com == nil {
+				return nil, fmt.Errorf("no commitment from chosen signer")
+			}
+			for _, c := range chosen {
+				if c.Identifier.Equal(id) {
+					return nil, fmt.Errorf("signer chosen twice")
+				}
+			}
+			chosen = append(chosen, com)
+		}
+		if len(chosen) < co.Threshold {
+			return nil, fmt.Errorf("signing set is smaller than the threshold")
+		}
+	}
+
+	if co.Randomizer != nil {
+		return nil, fmt.Errorf("set RandomizerSeed instead of Randomizer")
+	}
+	if co.RandomizerSeed != nil && len(co.RandomizerSeed) != RandomizerSeedSize {
+		return nil, fmt.Errorf("randomizer seed must be %d bytes", RandomizerSeedSize)
+	}
+	pkg := &SigningPackage{
+		SessionID:      co.SessionID,
+		Message:        co.Message,
+		Commitments:    chosen,
+		RandomizerSeed: co.RandomizerSeed,
+		DerivationPath: co.DerivationPath,
+		AdaptorPoint:   co.AdaptorPoint,
+		BlindChallenge: co.BlindChallenge,
+	}
+	if co.RandomizerSeed != nil {
+		pkg.Randomizer = DeriveRandomizer(co.RandomizerSeed, pkg)
+	}
+
+	state := NewState(co.Ciphersuite, co.Participants, co.GroupKey, co.Message, nil, nil)
+	state.randomizer = pkg.Randomizer
+	if len(co.DerivationPath) > 0 {
+		if err := state.SetDerivationPath(co.DerivationPath...); err != nil {
+			return nil, err
+		}
+	}
+	if co.AdaptorPoint != nil {
+		if err := state.SetAdaptorPoint(co.AdaptorPoint); err != nil {
+			return nil, err
+		}
+	}
+	if co.BlindChallenge != nil {
+		if err := state.SetBlindChallenge(co.BlindChallenge); err != nil {
+			return nil, err
+		}
+	}
+	if err := state.prepare(chosen); err != nil {
+		return nil, err
+	}
+	co.state = state
+	co.Randomizer = pkg.Randomizer
+	co.pkg = pkg
+	return co.pkg, nil
+}
+
+// AddSignatureShare verifies a second-round share as soon as it arrives, and
+// keeps it if it is valid.
+func (co *Coordinator) AddSignatureShare(share *SignatureShare) error {
+	if co.state == nil {
+		return fmt.Errorf("signing set not chosen yet")
+	}
+	if share == nil || share.Identifier == nil || share.Share == nil {
+		return fmt.Errorf("incomplete signature share")
+	}
+	if co.pkg.commitment(share.Identifier) == nil {
+		return fmt.Errorf("share from a participant outside the signing set")
+	}
+	for _, s := range co.shares {
+		if s.Identifier.Equal(share.Identifier) {
+			return fmt.Errorf("duplicate signature share from participant")
+		}
+	}
+	ok, err := co.state.VerifySignatureShare(share)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w from participant %x", ErrInvalidShare, share.Identifier.Bytes())
+	}
+	co.shares = append(co.shares, share)
+	return nil
+}
+
+// Missing returns the identifiers in the signing set that have not yet
+// provided a valid share.
+func (co *Coordinator) Missing() []*Scalar {
+	if co.pkg == nil {
+		return nil
+	}
+	var missing []*Scalar
+	for _, c := range co.pkg.Commitments {
+		found := false
+		for _, s := range co.shares {
+			if s.Identifier.Equal(c.Identifier) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, c.Identifier)
+		}
+	}
+	return missing
+}
+
+// Aggregate produces the final signature once every signer in the signing
+// set has provided a valid share.
+func (co *Coordinator) Aggregate() (*Signature, error) {
+	if co.state == nil {
+		return nil, fmt.Errorf("signing set not chosen yet")
+	}
+	if missing := len(co.Missing()); missing > 0 {
+		return nil, fmt.Errorf("%d signature shares missing", missing)
+	}
+	return co.state.Aggregate(co.shares)
+}
+
+// AggregatePreSignature produces the pre-signature of an adaptor signature
+// once every signer in the signing set has provided a valid share.
+func (co *Coordinator) AggregatePreSignature() (*PreSignature, error) {
+	if co.state == nil {
+		return nil, fmt.Errorf("signing set not chosen yet")
+	}
+	if missing := len(co.Missing()); missing > 0 {
+		return nil, fmt.Errorf("%d signature shares missing", missing)
+	}
+	return co.state.AggregatePreSignature(co.shares)
+}
+
+func (co *Coordinator) participant(id *Scalar) *Participant {
+	for _, p := range co.Participants {
+		if p.Identifier.Equal(id) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (co *Coordinator) commitment(id *Scalar) *Commitment {
+	for _, c := range co.received {
+		if c.Identifier.Equal(id) {
+			return c
+		}
+	}
+	return nil
+}
+
+// ValidateCommitment rejects commitments with missing fields or identity
+// elements.
+func ValidateCommitment(com *Commitment) error {
+	if com == nil || com.Identifier == nil || com.Hiding == nil || com.Binding == nil {
+		return fmt.Errorf("incomplete commitment")
+	}
+	if com.Hiding.IsIdentity() || com.Binding.IsIdentity() {
+		return fmt.Errorf("commitment contains the identity element")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+
+	"filippo.io/edwards25519"
+)
+
+// DeriveTweak computes the tweak for a single derivation step: a hash of the
+// parent key and the label.
+func DeriveTweak(parent *GroupKey, label []byte) *Scalar {
+	h := sha512.New()
+	h.Write([]byte(ContextString))
+	h.Write([]byte("derive"))
+	h.Write(parent.Bytes())
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(label))))
+	h.Write(label)
+	s, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		// This should not happen
+		panic(err)
+	}
+	return &Scalar{s: s}
+}
+
+// DeriveChildKey walks a derivation path from a parent group key, returning
+// the child key and the total tweak from the parent. Each label adds a public
+// tweak, child = parent + [t]B, so the tweak for a path is the sum of the
+// tweaks along it.
+//
+// Since the tweaks are public, anyone who learns the secret key of a child,
+// for example from a single-party signer, also learns the parent's.
+func DeriveChildKey(parent *GroupKey, path ...[]byte) (*GroupKey, *Scalar) {
+	key := parent
+	tweak := NewScalar()
+	for _, label := range path {
+		t := DeriveTweak(key, label)
+		tweak.Add(tweak, t)
+		key = &GroupKey{Element: NewElement().Add(key.Element, NewElement().Mul(t, nil))}
+	}
+	return key, tweak
+}
+
+// SetDerivationPath makes this State sign for a child of its group key, with
+// its existing share: the challenge is computed over the child key, and the
+// aggregator adds t * c to the response, as for a re-randomized signature. It
+// must be called before Sign.
+func (s *State) SetDerivationPath(path ...[]byte) error {
+	if s.groupCommitment != nil {
+		return fmt.Errorf("derivation path must be set before signing")
+	}
+	if len(path) == 0 {
+		return fmt.Errorf("empty derivation path")
+	}
+	if s.derivationPath != nil && !equalPaths(s.derivationPath, path) {
+		return fmt.Errorf("a different derivation path is already set")
+	}
+	_, tweak := DeriveChildKey(s.GroupKey, path...)
+	s.derivationPath = path
+	s.keyTweak = tweak
+	return nil
+}
+
+func equalPaths(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
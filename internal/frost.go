@@ -2,7 +2,23 @@ package internal
 
 import (
 	"crypto/subtle"
+	"errors"
 	"fmt"
+	"io"
+)
+
+var (
+	// ErrNonceReused is returned when a nonce has already been consumed, or
+	// is already attached to another State.
+	ErrNonceReused = errors.New("nonce has already been used")
+
+	// ErrAlreadySigned is returned when Sign is called more than once on the
+	// same State.
+	ErrAlreadySigned = errors.New("signature share already produced for this state")
+
+	// ErrNoNonce is returned when Sign is called on a State that never had a
+	// nonce attached.
+	ErrNoNonce = errors.New("no nonce attached to this state")
 )
 
 // State holds the state of a FROST signing ceremony.
@@ -15,8 +31,23 @@ type State struct {
 	SignatureShares []*SignatureShare
 	MyIdentifier    *Scalar
 	MySecretShare   *SecretShare
-	MyNonce         *Nonce
 	MyCommitment    *Commitment
+	// Rand is the source of randomness for nonces. Defaults to crypto/rand.
+	Rand io.Reader
+	// Policy, if set, must approve every signature share before it is released
+	Policy Policy
+	// SessionID is shown to the Policy; SignPackage sets it from the package
+	SessionID       []byte
+	randomizer      *Scalar
+	keyTweak        *Scalar
+	derivationPath  [][]byte
+	adaptorPoint    *Element
+	blindChallenge  *Scalar
+	blind           *BlindSigner
+	blindClosed     bool
+	myNonce         *Nonce
+	nonceDiscarded  bool
+	signed          bool
 	bindingFactors  []*BindingFactor
 	groupCommitment *Element
 	challenge       *Scalar
@@ -36,42 +67,129 @@ func NewState(c Ciphersuite, participants []*Participant, groupKey *GroupKey, ms
 
 // Commit performs the first round of the FROST protocol.
 func (s *State) Commit() (*Commitment, error) {
-	hidingNonce, err := NonceGenerate(s.Ciphersuite, s.MySecretShare.Scalar)
-	if err != nil {
-		return nil, err
+	if s.signed {
+		return nil, ErrAlreadySigned
 	}
-	bindingNonce, err := NonceGenerate(s.Ciphersuite, s.MySecretShare.Scalar)
+	nonce, err := NewNonce(s.Ciphersuite, s.Rand, s.MySecretShare.Scalar)
 	if err != nil {
 		return nil, err
 	}
-	s.MyNonce = &Nonce{
-		Hiding:  hidingNonce,
-		Binding: bindingNonce,
+	if err := s.SetNonce(nonce); err != nil {
+		return nil, err
 	}
+	return s.MyCommitment, nil
+}
 
-	hidingCommitment := NewElement().Mul(hidingNonce, nil)
-	bindingCommitment := NewElement().Mul(bindingNonce, nil)
-
+// SetNonce attaches a nonce generated elsewhere to this state, and derives
+// the matching commitment. Any nonce previously attached is zeroized.
+//
+// A nonce can only ever be attached to one State.
+func (s *State) SetNonce(n *Nonce) error {
+	if s.signed {
+		return ErrAlreadySigned
+	}
+	if n == nil || n.Hiding == nil || n.Binding == nil {
+		return fmt.Errorf("incomplete nonce")
+	}
+	zero := NewScalar()
+	if n.Hiding.Equal(zero) || n.Binding.Equal(zero) {
+		return fmt.Errorf("nonce is zero, or was zeroized")
+	}
+	if !n.claim() {
+		return ErrNonceReused
+	}
+	if s.myNonce != nil {
+		s.myNonce.Zeroize()
+	}
+	s.myNonce = n
 	s.MyCommitment = &Commitment{
 		Identifier: s.MyIdentifier,
-		Hiding:     hidingCommitment,
-		Binding:    bindingCommitment,
+		Hiding:     NewElement().Mul(n.Hiding, nil),
+		Binding:    NewElement().Mul(n.Binding, nil),
 	}
-	return s.MyCommitment, nil
+	return nil
 }
 
 // Sign performs the second round of the FROST protocol.
+//
+// The nonce from Commit is consumed and zeroized by this call, whether or not
+// it succeeds, so Sign can only be called once per State. The one exception is
+// a refusal by the State's Policy, which is checked first; a refusal still
+// ends a blind session, though.
 func (s *State) Sign(commitments []*Commitment) (*SignatureShare, error) {
-	s.Commitments = commitments
-	s.bindingFactors = ComputeBindingFactors(s.Ciphersuite, s.GroupKey.Element, s.Commitments, s.Message)
+	if err := s.approve(commitments); err != nil {
+		return nil, s.refused(err)
+	}
+	return s.sign(commitments)
+}
 
-	var err error
-	s.groupCommitment, err = ComputeGroupCommitment(s.Commitments, s.bindingFactors)
+// Close ends the session without signing: the nonce is zeroized, and the
+// State can never sign. For a State from a BlindSigner, this frees its
+// session. Closing a State more than once is harmless.
+func (s *State) Close() {
+	s.discardNonce()
+}
+
+// Consult the Policy, if any, without touching the nonce.
+func (s *State) approve(commitments []*Commitment) error {
+	r, err := s.reserve(commitments)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	r.Commit()
+	return nil
+}
 
-	s.challenge = ComputeChallenge(s.Ciphersuite, s.groupCommitment, s.GroupKey.Element, s.Message)
+// A blind session ends when the Policy refuses it, so that a requester cannot
+// hold the session open with requests the signer will never approve.
+func (s *State) refused(err error) error {
+	if s.blind != nil && errors.Is(err, ErrRefused) {
+		s.discardNonce()
+	}
+	return err
+}
+
+// Reserve the Policy's approval, if there is a Policy, without touching the
+// nonce.
+func (s *State) reserve(commitments []*Commitment) (*Reservation, error) {
+	if s.MySecretShare == nil || s.Policy == nil {
+		return &Reservation{}, nil
+	}
+	if s.signed {
+		return nil, ErrAlreadySigned
+	}
+	if s.myNonce == nil {
+		return nil, s.noNonce()
+	}
+	r, err := reserve(s.Policy, s.signingRequest(commitments))
+	if err != nil {
+		return nil, asRefusal(err, s.MyIdentifier)
+	}
+	return r, nil
+}
+
+func (s *State) sign(commitments []*Commitment) (*SignatureShare, error) {
+	var nonce *Nonce
+	if s.MySecretShare != nil {
+		if s.signed {
+			return nil, ErrAlreadySigned
+		}
+		if s.myNonce == nil {
+			return nil, s.noNonce()
+		}
+		if s.blind != nil && s.blindChallenge == nil {
+			return nil, fmt.Errorf("blind signer has no blind challenge")
+		}
+		nonce = s.myNonce
+		s.myNonce = nil
+		s.signed = true
+		defer nonce.Zeroize()
+		s.endBlindSession()
+	}
+
+	if err := s.prepare(commitments); err != nil {
+		return nil, err
+	}
 	if s.MySecretShare == nil {
 		// An aggregation-only state allows the coordinator to compute the signature without holding a share
 		return nil, nil
@@ -89,7 +207,7 @@ func (s *State) Sign(commitments []*Commitment) (*SignatureShare, error) {
 	}
 
 	sigShare := NewScalar()
-	sigShare.Add(s.MyNonce.Hiding, NewScalar().Mul(s.MyNonce.Binding, bindingFactor))
+	sigShare.Add(nonce.Hiding, NewScalar().Mul(nonce.Binding, bindingFactor))
 	tmp := NewScalar().Mul(lambda_i, s.MySecretShare.Scalar)
 	tmp.Mul(tmp, s.challenge)
 	sigShare.Add(sigShare, tmp)
@@ -100,23 +218,121 @@ func (s *State) Sign(commitments []*Commitment) (*SignatureShare, error) {
 	}, nil
 }
 
+// The error for a State without a nonce: ErrNonceReused if it was handed over
+// or thrown away, and ErrNoNonce if there never was one.
+func (s *State) noNonce() error {
+	if s.nonceDiscarded {
+		return ErrNonceReused
+	}
+	return ErrNoNonce
+}
+
+// Zeroize and detach the nonce, so this State can never sign with it.
+func (s *State) discardNonce() {
+	if s.myNonce != nil {
+		s.myNonce.Zeroize()
+		s.myNonce = nil
+		s.nonceDiscarded = true
+	}
+	s.endBlindSession()
+}
+
+// Compute the binding factors, group commitment and challenge for a
+// commitment list.
+func (s *State) prepare(commitments []*Commitment) error {
+	s.Commitments = commitments
+	groupKey := s.effectiveGroupKey()
+	s.bindingFactors = ComputeBindingFactors(s.Ciphersuite, groupKey, s.Commitments, s.Message)
+
+	var err error
+	s.groupCommitment, err = ComputeGroupCommitment(s.Commitments, s.bindingFactors)
+	if err != nil {
+		return err
+	}
+
+	if s.blindChallenge != nil {
+		if s.totalTweak() != nil || s.adaptorPoint != nil {
+			return fmt.Errorf("blind signing cannot be combined with key tweaks or adaptor points")
+		}
+		s.challenge = s.blindChallenge
+		return nil
+	}
+	s.challenge = ComputeChallenge(s.Ciphersuite, s.signatureNonce(), groupKey, s.Message)
+	return nil
+}
+
+// The R of the final signature: the group commitment, shifted by the adaptor
+// point for an adaptor signature.
+func (s *State) signatureNonce() *Element {
+	if s.adaptorPoint == nil {
+		return s.groupCommitment
+	}
+	return NewElement().Add(s.groupCommitment, s.adaptorPoint)
+}
+
+// EffectiveGroupKey returns the key this State's signature will verify under,
+// after any randomizer and derivation path are applied.
+func (s *State) EffectiveGroupKey() *GroupKey {
+	return &GroupKey{Element: s.effectiveGroupKey()}
+}
+
+// RandomizedGroupKey returns the key this State's signature will verify under.
+// It is the same as EffectiveGroupKey, which also covers derivation paths.
+func (s *State) RandomizedGroupKey() *GroupKey {
+	return s.EffectiveGroupKey()
+}
+
+// The group key the challenge is computed over.
+func (s *State) effectiveGroupKey() *Element {
+	tweak := s.totalTweak()
+	if tweak == nil {
+		return s.GroupKey.Element
+	}
+	return NewElement().Add(s.GroupKey.Element, NewElement().Mul(tweak, nil))
+}
+
+// The sum of the randomizer and the derivation tweak, or nil if neither is
+// set. Both are additive tweaks of the group key, so they compose.
+func (s *State) totalTweak() *Scalar {
+	switch {
+	case s.randomizer == nil:
+		return s.keyTweak
+	case s.keyTweak == nil:
+		return s.randomizer
+	default:
+		return NewScalar().Add(s.randomizer, s.keyTweak)
+	}
+}
+
 // Aggregate aggregates the signature shares to produce the final signature.
 func (s *State) Aggregate(shares []*SignatureShare) (*Signature, error) {
 	if s.groupCommitment == nil {
 		return nil, fmt.Errorf("group commitment not computed")
 	}
-
-	z := NewScalar()
-	for _, share := range shares {
-		z.Add(z, share.Share)
+	if s.adaptorPoint != nil {
+		return nil, fmt.Errorf("an adaptor signature must be aggregated with AggregatePreSignature")
 	}
 
 	return &Signature{
 		R: s.groupCommitment,
-		Z: z,
+		Z: s.aggregateResponse(shares),
 	}, nil
 }
 
+// Sum the signature shares into the response z.
+func (s *State) aggregateResponse(shares []*SignatureShare) *Scalar {
+	z := NewScalar()
+	for _, share := range shares {
+		z.Add(z, share.Share)
+	}
+	if tweak := s.totalTweak(); tweak != nil {
+		// The signers' shares only cover the untweaked key, so the tweak's
+		// share of the response is added here: z += tweak * c
+		z.Add(z, NewScalar().Mul(tweak, s.challenge))
+	}
+	return z
+}
+
 func (s *State) SetGroupCommitment(e *Element) {
 	s.groupCommitment = e
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"fmt"
+	"io"
 	"sort"
 )
 
@@ -15,8 +16,17 @@ type BindingFactor struct {
 
 // NonceGenerate generates a nonce for a participant.
 func NonceGenerate(c Ciphersuite, secret *Scalar) (*Scalar, error) {
+	return NonceGenerateFrom(c, rand.Reader, secret)
+}
+
+// NonceGenerateFrom generates a nonce for a participant, drawing its 32 random
+// bytes from r. A nil reader means crypto/rand.
+func NonceGenerateFrom(c Ciphersuite, r io.Reader, secret *Scalar) (*Scalar, error) {
+	if r == nil {
+		r = rand.Reader
+	}
 	randomBytes := make([]byte, 32)
-	_, err := rand.Read(randomBytes)
+	_, err := io.ReadFull(r, randomBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +34,20 @@ func NonceGenerate(c Ciphersuite, secret *Scalar) (*Scalar, error) {
 	return c.H3(append(randomBytes, secretEnc...)), nil
 }
 
+// NewNonce generates a fresh hiding and binding nonce pair for a participant.
+// A nil reader means crypto/rand.
+func NewNonce(c Ciphersuite, r io.Reader, secret *Scalar) (*Nonce, error) {
+	hidingNonce, err := NonceGenerateFrom(c, r, secret)
+	if err != nil {
+		return nil, err
+	}
+	bindingNonce, err := NonceGenerateFrom(c, r, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Nonce{Hiding: hidingNonce, Binding: bindingNonce}, nil
+}
+
 // DeriveInterpolatingValue derives the interpolating value for a participant.
 func DeriveInterpolatingValue(L []*Scalar, xi *Scalar) (*Scalar, error) {
 	// Ensure xi is in L
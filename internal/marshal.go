@@ -0,0 +1,473 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalText encodes the result of a MarshalBinary call in base64url. Every
+// type's text encoding is its canonical binary encoding in this form.
+func MarshalText(b []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, base64.URLEncoding.EncodedLen(len(b)))
+	base64.URLEncoding.Encode(out, b)
+	return out, nil
+}
+
+// UnmarshalText decodes base64url text for UnmarshalBinary.
+func UnmarshalText(text []byte) ([]byte, error) {
+	out := make([]byte, base64.URLEncoding.DecodedLen(len(text)))
+	n, err := base64.URLEncoding.Decode(out, text)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// Decode a base64url JSON string field into a canonical scalar.
+func jsonScalar(field string) (*Scalar, error) {
+	raw, err := base64.URLEncoding.DecodeString(field)
+	if err != nil {
+		return nil, err
+	}
+	r := &byteReader{b: raw}
+	s := r.scalar()
+	if r.err != nil || len(r.b) != 0 {
+		return nil, fmt.Errorf("malformed scalar")
+	}
+	return s, nil
+}
+
+// Decode a base64url JSON string field into a non-zero identifier.
+func jsonIdentifier(field string) (*Scalar, error) {
+	raw, err := base64.URLEncoding.DecodeString(field)
+	if err != nil {
+		return nil, err
+	}
+	r := &byteReader{b: raw}
+	id := r.identifier()
+	if r.err != nil || len(r.b) != 0 {
+		return nil, fmt.Errorf("malformed identifier")
+	}
+	return id, nil
+}
+
+// Decode a base64url JSON string field into a canonical point.
+func jsonElement(field string) (*Element, error) {
+	raw, err := base64.URLEncoding.DecodeString(field)
+	if err != nil {
+		return nil, err
+	}
+	r := &byteReader{b: raw}
+	e := r.element()
+	if r.err != nil || len(r.b) != 0 {
+		return nil, fmt.Errorf("malformed element")
+	}
+	return e, nil
+}
+
+func (s *Scalar) MarshalBinary() ([]byte, error) {
+	return s.EncodeBinary(), nil
+}
+
+func (s *Scalar) UnmarshalBinary(b []byte) error {
+	v, err := ScalarFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*s = *v
+	return nil
+}
+
+func (s *Scalar) MarshalText() ([]byte, error) {
+	return MarshalText(s.MarshalBinary())
+}
+
+func (s *Scalar) UnmarshalText(text []byte) error {
+	b, err := UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalBinary(b)
+}
+
+func (s *Scalar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.URLEncoding.EncodeToString(s.Bytes()))
+}
+
+func (s *Scalar) UnmarshalJSON(j []byte) error {
+	var field string
+	if err := json.Unmarshal(j, &field); err != nil {
+		return err
+	}
+	v, err := jsonScalar(field)
+	if err != nil {
+		return err
+	}
+	*s = *v
+	return nil
+}
+
+func (e *Element) MarshalBinary() ([]byte, error) {
+	return e.EncodeBinary(), nil
+}
+
+func (e *Element) UnmarshalBinary(b []byte) error {
+	v, err := ElementFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*e = *v
+	return nil
+}
+
+func (e *Element) MarshalText() ([]byte, error) {
+	return MarshalText(e.MarshalBinary())
+}
+
+func (e *Element) UnmarshalText(text []byte) error {
+	b, err := UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalBinary(b)
+}
+
+func (e *Element) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.URLEncoding.EncodeToString(e.Bytes()))
+}
+
+func (e *Element) UnmarshalJSON(j []byte) error {
+	var field string
+	if err := json.Unmarshal(j, &field); err != nil {
+		return err
+	}
+	v, err := jsonElement(field)
+	if err != nil {
+		return err
+	}
+	*e = *v
+	return nil
+}
+
+func (gk *GroupKey) MarshalBinary() ([]byte, error) {
+	return gk.EncodeBinary(), nil
+}
+
+func (gk *GroupKey) UnmarshalBinary(b []byte) error {
+	v, err := GroupKeyFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*gk = *v
+	return nil
+}
+
+func (gk *GroupKey) MarshalText() ([]byte, error) {
+	return MarshalText(gk.MarshalBinary())
+}
+
+func (gk *GroupKey) UnmarshalText(text []byte) error {
+	b, err := UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return gk.UnmarshalBinary(b)
+}
+
+func (gk *GroupKey) MarshalJSON() ([]byte, error) {
+	return gk.Element.MarshalJSON()
+}
+
+func (gk *GroupKey) UnmarshalJSON(j []byte) error {
+	e := new(Element)
+	if err := e.UnmarshalJSON(j); err != nil {
+		return err
+	}
+	if e.IsIdentity() {
+		return fmt.Errorf("group key is the identity")
+	}
+	gk.Element = e
+	return nil
+}
+
+type participantJSON struct {
+	Id  string `json:"i"`
+	Pub string `json:"p"`
+}
+
+func (p *Participant) MarshalBinary() ([]byte, error) {
+	return p.EncodeBinary(), nil
+}
+
+func (p *Participant) UnmarshalBinary(b []byte) error {
+	v, err := ParticipantFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*p = *v
+	return nil
+}
+
+func (p *Participant) MarshalText() ([]byte, error) {
+	return MarshalText(p.MarshalBinary())
+}
+
+func (p *Participant) UnmarshalText(text []byte) error {
+	b, err := UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return p.UnmarshalBinary(b)
+}
+
+func (p *Participant) MarshalJSON() ([]byte, error) {
+	id, pub := p.Bytes()
+	return json.Marshal(participantJSON{
+		Id:  base64.URLEncoding.EncodeToString(id),
+		Pub: base64.URLEncoding.EncodeToString(pub),
+	})
+}
+
+func (p *Participant) UnmarshalJSON(j []byte) error {
+	var v participantJSON
+	if err := json.Unmarshal(j, &v); err != nil {
+		return err
+	}
+	id, err := jsonIdentifier(v.Id)
+	if err != nil {
+		return err
+	}
+	pub, err := jsonElement(v.Pub)
+	if err != nil {
+		return err
+	}
+	p.Identifier, p.PublicKeyShare = id, pub
+	return nil
+}
+
+type secretShareJSON struct {
+	Id     string `json:"i"`
+	Secret string `json:"s"`
+}
+
+func (ss *SecretShare) MarshalBinary() ([]byte, error) {
+	return ss.EncodeBinary(), nil
+}
+
+func (ss *SecretShare) UnmarshalBinary(b []byte) error {
+	v, err := SecretShareFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*ss = *v
+	return nil
+}
+
+func (ss *SecretShare) MarshalText() ([]byte, error) {
+	return MarshalText(ss.MarshalBinary())
+}
+
+func (ss *SecretShare) UnmarshalText(text []byte) error {
+	b, err := UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return ss.UnmarshalBinary(b)
+}
+
+func (ss *SecretShare) MarshalJSON() ([]byte, error) {
+	id, secret := ss.Bytes()
+	return json.Marshal(secretShareJSON{
+		Id:     base64.URLEncoding.EncodeToString(id),
+		Secret: base64.URLEncoding.EncodeToString(secret),
+	})
+}
+
+func (ss *SecretShare) UnmarshalJSON(j []byte) error {
+	var v secretShareJSON
+	if err := json.Unmarshal(j, &v); err != nil {
+		return err
+	}
+	id, err := jsonIdentifier(v.Id)
+	if err != nil {
+		return err
+	}
+	secret, err := jsonScalar(v.Secret)
+	if err != nil {
+		return err
+	}
+	ss.Identifier, ss.Scalar = id, secret
+	return nil
+}
+
+func (com *Commitment) MarshalBinary() ([]byte, error) {
+	return com.EncodeBinary(), nil
+}
+
+func (com *Commitment) UnmarshalBinary(b []byte) error {
+	v, err := CommitmentFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*com = *v
+	return nil
+}
+
+func (com *Commitment) MarshalText() ([]byte, error) {
+	return MarshalText(com.MarshalBinary())
+}
+
+func (com *Commitment) UnmarshalText(text []byte) error {
+	b, err := UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return com.UnmarshalBinary(b)
+}
+
+// MarshalJSON is the same as EncodeJSON.
+func (com *Commitment) MarshalJSON() ([]byte, error) {
+	return com.EncodeJSON()
+}
+
+// UnmarshalJSON accepts the output of EncodeJSON. Unlike CommitmentFromJSON,
+// it rejects zero identifiers, non-canonical points and identity elements.
+func (com *Commitment) UnmarshalJSON(j []byte) error {
+	var v struct {
+		Id   string `json:"i"`
+		Hide string `json:"h"`
+		Bind string `json:"b"`
+	}
+	if err := json.Unmarshal(j, &v); err != nil {
+		return err
+	}
+	id, err := jsonIdentifier(v.Id)
+	if err != nil {
+		return err
+	}
+	hiding, err := jsonElement(v.Hide)
+	if err != nil {
+		return err
+	}
+	binding, err := jsonElement(v.Bind)
+	if err != nil {
+		return err
+	}
+	out := &Commitment{Identifier: id, Hiding: hiding, Binding: binding}
+	if err := ValidateCommitment(out); err != nil {
+		return err
+	}
+	*com = *out
+	return nil
+}
+
+func (sigshare *SignatureShare) MarshalBinary() ([]byte, error) {
+	return sigshare.EncodeBinary(), nil
+}
+
+func (sigshare *SignatureShare) UnmarshalBinary(b []byte) error {
+	v, err := SignatureShareFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*sigshare = *v
+	return nil
+}
+
+func (sigshare *SignatureShare) MarshalText() ([]byte, error) {
+	return MarshalText(sigshare.MarshalBinary())
+}
+
+func (sigshare *SignatureShare) UnmarshalText(text []byte) error {
+	b, err := UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return sigshare.UnmarshalBinary(b)
+}
+
+// MarshalJSON is the same as EncodeJSON.
+func (sigshare *SignatureShare) MarshalJSON() ([]byte, error) {
+	return sigshare.EncodeJSON()
+}
+
+// UnmarshalJSON accepts the output of EncodeJSON. Unlike
+// SignatureShareFromJSON, it rejects zero identifiers and non-canonical
+// scalars.
+func (sigshare *SignatureShare) UnmarshalJSON(j []byte) error {
+	var v struct {
+		Id    string `json:"i"`
+		Share string `json:"s"`
+	}
+	if err := json.Unmarshal(j, &v); err != nil {
+		return err
+	}
+	id, err := jsonIdentifier(v.Id)
+	if err != nil {
+		return err
+	}
+	share, err := jsonScalar(v.Share)
+	if err != nil {
+		return err
+	}
+	sigshare.Identifier, sigshare.Share = id, share
+	return nil
+}
+
+type signatureJSON struct {
+	R string `json:"r"`
+	Z string `json:"z"`
+}
+
+func (s *Signature) MarshalBinary() ([]byte, error) {
+	return s.EncodeBinary(), nil
+}
+
+func (s *Signature) UnmarshalBinary(b []byte) error {
+	v, err := SignatureFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*s = *v
+	return nil
+}
+
+func (s *Signature) MarshalText() ([]byte, error) {
+	return MarshalText(s.MarshalBinary())
+}
+
+func (s *Signature) UnmarshalText(text []byte) error {
+	b, err := UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalBinary(b)
+}
+
+func (s *Signature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(signatureJSON{
+		R: base64.URLEncoding.EncodeToString(s.R.Bytes()),
+		Z: base64.URLEncoding.EncodeToString(s.Z.Bytes()),
+	})
+}
+
+func (s *Signature) UnmarshalJSON(j []byte) error {
+	var v signatureJSON
+	if err := json.Unmarshal(j, &v); err != nil {
+		return err
+	}
+	r, err := jsonElement(v.R)
+	if err != nil {
+		return err
+	}
+	z, err := jsonScalar(v.Z)
+	if err != nil {
+		return err
+	}
+	s.R, s.Z = r, z
+	return nil
+}
@@ -1,5 +1,15 @@
 package internal
 
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
 // MessageType is the type of a message.
 type MessageType int
 
@@ -10,11 +20,408 @@ const (
 	MessageTypeKeyGen1
 	// MessageTypeKeyGen2 is the message for the second round of the DKG protocol.
 	MessageTypeKeyGen2
+	// MessageTypeCommitment carries a signer's first-round commitment.
+	MessageTypeCommitment
+	// MessageTypeSigningPackage carries the coordinator's signing package.
+	MessageTypeSigningPackage
+	// MessageTypeSignatureShare carries a signer's second-round share.
+	MessageTypeSignatureShare
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case MessageTypeNone:
+		return "none"
+	case MessageTypeKeyGen1:
+		return "keygen round 1"
+	case MessageTypeKeyGen2:
+		return "keygen round 2"
+	case MessageTypeCommitment:
+		return "commitment"
+	case MessageTypeSigningPackage:
+		return "signing package"
+	case MessageTypeSignatureShare:
+		return "signature share"
+	default:
+		return fmt.Sprintf("MessageType(%d)", int(t))
+	}
+}
+
+var (
+	// ErrUnknownSession is returned when a message names a session that has
+	// no registered handler.
+	ErrUnknownSession = errors.New("unknown session")
+
+	// ErrWrongRecipient is returned when a message is addressed to someone
+	// else.
+	ErrWrongRecipient = errors.New("message addressed to another participant")
 )
 
 // Message is a generic message that can be sent between participants.
+//
+// Exactly one payload field is set, matching Type. DKG rounds carry an opaque
+// Payload for the DKG implementation to decode.
 type Message struct {
-	From           *Scalar
+	Type MessageType
+	// SessionID identifies the ceremony the message belongs to
+	SessionID []byte
+	// From is the sender, or nil for a coordinator
+	From *Scalar
+	// To is the recipient, or nil for a broadcast
+	To *Scalar
+
 	Commitment     *Commitment
+	SigningPackage *SigningPackage
 	SignatureShare *SignatureShare
+	Payload        []byte
+}
+
+// Validate checks that the payload matches the type, and that signer payloads
+// come from their sender. Every encoder and decoder calls it.
+func (m *Message) Validate() error {
+	var ok bool
+	switch m.Type {
+	case MessageTypeKeyGen1, MessageTypeKeyGen2:
+		ok = m.Payload != nil && m.Commitment == nil && m.SigningPackage == nil && m.SignatureShare == nil
+	case MessageTypeCommitment:
+		ok = m.Commitment != nil && m.SigningPackage == nil && m.SignatureShare == nil && m.Payload == nil
+		if ok && (m.From == nil || !m.From.Equal(m.Commitment.Identifier)) {
+			return fmt.Errorf("commitment does not match its sender")
+		}
+	case MessageTypeSigningPackage:
+		ok = m.SigningPackage != nil && m.Commitment == nil && m.SignatureShare == nil && m.Payload == nil
+	case MessageTypeSignatureShare:
+		ok = m.SignatureShare != nil && m.Commitment == nil && m.SigningPackage == nil && m.Payload == nil
+		if ok && (m.From == nil || !m.From.Equal(m.SignatureShare.Identifier)) {
+			return fmt.Errorf("signature share does not match its sender")
+		}
+	default:
+		return fmt.Errorf("unknown message type %s", m.Type)
+	}
+	if !ok {
+		return fmt.Errorf("payload does not match message type %s", m.Type)
+	}
+	return nil
+}
+
+// The binary encoding of the payload.
+func (m *Message) payload() []byte {
+	switch m.Type {
+	case MessageTypeCommitment:
+		return m.Commitment.EncodeBinary()
+	case MessageTypeSigningPackage:
+		return m.SigningPackage.EncodeBinary()
+	case MessageTypeSignatureShare:
+		return m.SignatureShare.EncodeBinary()
+	default:
+		return m.Payload
+	}
+}
+
+// Decode a payload into the field matching the type.
+func (m *Message) setPayload(b []byte) error {
+	var err error
+	switch m.Type {
+	case MessageTypeCommitment:
+		m.Commitment, err = CommitmentFromBinary(b)
+	case MessageTypeSigningPackage:
+		m.SigningPackage, err = SigningPackageFromBinary(b)
+	case MessageTypeSignatureShare:
+		m.SignatureShare, err = SignatureShareFromBinary(b)
+	default:
+		m.Payload = append([]byte{}, b...)
+	}
+	return err
+}
+
+// EncodeBinary encodes the message envelope: the type, the session ID, the
+// sender and recipient (each a presence byte and an identifier), and the
+// payload in its own binary encoding.
+func (m *Message) EncodeBinary() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	out := wireHeader(WireMessage)
+	out = append(out, byte(m.Type))
+	out = binary.BigEndian.AppendUint32(out, uint32(len(m.SessionID)))
+	out = append(out, m.SessionID...)
+	out = appendOptionalScalar(out, m.From)
+	out = appendOptionalScalar(out, m.To)
+	payload := m.payload()
+	out = binary.BigEndian.AppendUint32(out, uint32(len(payload)))
+	return append(out, payload...), nil
+}
+
+// MessageFromBinary decodes a message envelope and its payload.
+func MessageFromBinary(b []byte) (*Message, error) {
+	r, err := openWire(b, WireMessage)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Message)
+	if t := r.next(1); t != nil {
+		m.Type = MessageType(t[0])
+	}
+	if sid := r.next(r.count(1)); len(sid) > 0 {
+		m.SessionID = append([]byte{}, sid...)
+	}
+	m.From = r.optionalIdentifier()
+	m.To = r.optionalIdentifier()
+	payload := r.next(r.count(1))
+	if err := closeWire(r, WireMessage); err != nil {
+		return nil, err
+	}
+	if err := m.setPayload(payload); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func appendOptionalScalar(out []byte, s *Scalar) []byte {
+	if s == nil {
+		return append(out, 0)
+	}
+	return append(append(out, 1), s.Bytes()...)
+}
+
+func (r *byteReader) optionalIdentifier() *Scalar {
+	flag := r.next(1)
+	switch {
+	case flag == nil:
+		return nil
+	case flag[0] == 0:
+		return nil
+	case flag[0] == 1:
+		return r.identifier()
+	default:
+		r.err = fmt.Errorf("invalid presence flag")
+		return nil
+	}
+}
+
+type messageJSON struct {
+	Type      MessageType `json:"t"`
+	SessionID string      `json:"sid,omitempty"`
+	From      string      `json:"from,omitempty"`
+	To        string      `json:"to,omitempty"`
+	Payload   string      `json:"p"`
+}
+
+// Encode an optional identifier for a JSON envelope.
+func jsonOptionalScalar(s *Scalar) string {
+	if s == nil {
+		return ""
+	}
+	return base64.URLEncoding.EncodeToString(s.Bytes())
+}
+
+// Decode an optional identifier from a JSON envelope.
+func jsonOptionalIdentifier(field string) (*Scalar, error) {
+	if field == "" {
+		return nil, nil
+	}
+	return jsonIdentifier(field)
+}
+
+// EncodeJSON encodes the message envelope as JSON. The payload is its binary
+// encoding in base64url.
+func (m *Message) EncodeJSON() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(messageJSON{
+		Type:      m.Type,
+		SessionID: base64.URLEncoding.EncodeToString(m.SessionID),
+		From:      jsonOptionalScalar(m.From),
+		To:        jsonOptionalScalar(m.To),
+		Payload:   base64.URLEncoding.EncodeToString(m.payload()),
+	})
+}
+
+// MessageFromJSON decodes a message envelope from JSON.
+func MessageFromJSON(j []byte) (*Message, error) {
+	var v messageJSON
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+	sid, err := base64.URLEncoding.DecodeString(v.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := base64.URLEncoding.DecodeString(v.Payload)
+	if err != nil {
+		return nil, err
+	}
+	from, err := jsonOptionalIdentifier(v.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonOptionalIdentifier(v.To)
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{Type: v.Type, From: from, To: to}
+	if len(sid) > 0 {
+		m.SessionID = sid
+	}
+	if err := m.setPayload(payload); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Handler processes the messages of one ceremony, and returns the reply to
+// send, if any.
+type Handler interface {
+	Handle(msg *Message) (*Message, error)
+}
+
+// HandlerFunc adapts a function to the Handler interface.
+type HandlerFunc func(msg *Message) (*Message, error)
+
+func (f HandlerFunc) Handle(msg *Message) (*Message, error) {
+	return f(msg)
+}
+
+// Dispatcher routes decoded messages to the handler registered for their
+// session, or to the DKG handler for key generation rounds. Handlers are
+// called without the Dispatcher's lock held, so they may register or dispatch
+// themselves, but must do their own locking if messages are dispatched from
+// several goroutines.
+type Dispatcher struct {
+	mu         sync.Mutex
+	identifier *Scalar
+	sessions   map[string]Handler
+	dkg        Handler
+}
+
+// NewDispatcher creates a dispatcher for the participant with the given
+// identifier, or for a coordinator if it is nil. Messages addressed to anyone
+// else are rejected.
+func NewDispatcher(identifier *Scalar) *Dispatcher {
+	return &Dispatcher{identifier: identifier, sessions: make(map[string]Handler)}
+}
+
+// Register routes the signing messages of a session to a handler.
+func (d *Dispatcher) Register(sessionID []byte, h Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sessions[string(sessionID)] = h
+}
+
+// Unregister stops routing messages for a session.
+func (d *Dispatcher) Unregister(sessionID []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sessions, string(sessionID))
+}
+
+// RegisterDKG routes key generation messages to a DKG implementation.
+func (d *Dispatcher) RegisterDKG(h Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dkg = h
+}
+
+// Dispatch hands a message to its handler and returns the handler's reply.
+func (d *Dispatcher) Dispatch(msg *Message) (*Message, error) {
+	if err := msg.Validate(); err != nil {
+		return nil, err
+	}
+	if msg.To != nil && (d.identifier == nil || !msg.To.Equal(d.identifier)) {
+		return nil, ErrWrongRecipient
+	}
+
+	h, err := d.handler(msg)
+	if err != nil {
+		return nil, err
+	}
+	return h.Handle(msg)
+}
+
+// Look up the handler for a message.
+func (d *Dispatcher) handler(msg *Message) (Handler, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch msg.Type {
+	case MessageTypeKeyGen1, MessageTypeKeyGen2:
+		if d.dkg == nil {
+			return nil, fmt.Errorf("no DKG handler registered")
+		}
+		return d.dkg, nil
+	default:
+		h := d.sessions[string(msg.SessionID)]
+		if h == nil {
+			return nil, fmt.Errorf("%w %x", ErrUnknownSession, msg.SessionID)
+		}
+		return h, nil
+	}
+}
+
+// DispatchBinary decodes a binary message and dispatches it.
+func (d *Dispatcher) DispatchBinary(b []byte) (*Message, error) {
+	msg, err := MessageFromBinary(b)
+	if err != nil {
+		return nil, err
+	}
+	return d.Dispatch(msg)
+}
+
+// SignerHandler answers signing packages with this State's signature share.
+// The package must carry the session ID of the message it arrived in.
+func SignerHandler(s *State) Handler {
+	return HandlerFunc(func(msg *Message) (*Message, error) {
+		if msg.Type != MessageTypeSigningPackage {
+			return nil, fmt.Errorf("signer cannot handle %s messages", msg.Type)
+		}
+		if !bytes.Equal(msg.SigningPackage.SessionID, msg.SessionID) {
+			return nil, fmt.Errorf("signing package belongs to another session")
+		}
+		share, err := s.SignPackage(msg.SigningPackage)
+		if err != nil {
+			return nil, err
+		}
+		return &Message{
+			Type:           MessageTypeSignatureShare,
+			SessionID:      msg.SessionID,
+			From:           s.MyIdentifier,
+			To:             msg.From,
+			SignatureShare: share,
+		}, nil
+	})
+}
+
+// CoordinatorHandler feeds commitments and signature shares to a Coordinator.
+func CoordinatorHandler(co *Coordinator) Handler {
+	return HandlerFunc(func(msg *Message) (*Message, error) {
+		switch msg.Type {
+		case MessageTypeCommitment:
+			return nil, co.AddCommitment(msg.Commitment)
+		case MessageTypeSignatureShare:
+			return nil, co.AddSignatureShare(msg.SignatureShare)
+		default:
+			return nil, fmt.Errorf("coordinator cannot handle %s messages", msg.Type)
+		}
+	})
+}
+
+// BroadcastHandler feeds peers' commitments and signature shares to a
+// BroadcastSigner.
+func BroadcastHandler(b *BroadcastSigner) Handler {
+	return HandlerFunc(func(msg *Message) (*Message, error) {
+		switch msg.Type {
+		case MessageTypeCommitment:
+			return nil, b.AddCommitment(msg.Commitment)
+		case MessageTypeSignatureShare:
+			return nil, b.AddSignatureShare(msg.SignatureShare)
+		default:
+			return nil, fmt.Errorf("broadcast signer cannot handle %s messages", msg.Type)
+		}
+	})
 }
@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Version byte for exported states.
+	stateExportVersion = 1
+
+	// stateKeySize is the size of the key used to encrypt exported states.
+	stateKeySize = 32
+)
+
+// ReplayGuard remembers which nonces have been restored from an exported
+// State, so the same export cannot be restored (and signed with) twice.
+//
+// Implementations must be durable across process restarts.
+type ReplayGuard interface {
+	// Consume marks the nonce behind a commitment as used. It returns
+	// ErrNonceReused if the commitment was already consumed.
+	Consume(commitment *Commitment) error
+}
+
+// Export serializes a State between Commit and Sign, encrypting it (including
+// the private nonce) under a 32-byte key with AES-256-GCM.
+//
+// The nonce is handed over to the export: afterwards, this State can no longer
+// sign, and the export can be restored exactly once with RestoreState.
+//
+// Only the identifier, group key, message, participants and nonce are
+// exported. A State with a session ID, randomizer, key tweak, derivation
+// path, adaptor point or blind challenge, or one from a BlindSigner, cannot be
+// exported, since the restored State would sign without them.
+func (s *State) Export(key []byte) ([]byte, error) {
+	if s.signed {
+		return nil, ErrAlreadySigned
+	}
+	if s.myNonce == nil || s.MySecretShare == nil {
+		return nil, fmt.Errorf("only a committed signer state can be exported")
+	}
+	if len(s.SessionID) > 0 || s.randomizer != nil || s.keyTweak != nil || len(s.derivationPath) > 0 || s.adaptorPoint != nil || s.blindChallenge != nil || s.blind != nil {
+		return nil, fmt.Errorf("state uses features that cannot be exported")
+	}
+	aead, err := stateAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var pt []byte
+	pt = append(pt, s.MyIdentifier.Bytes()...)
+	pt = append(pt, s.GroupKey.Bytes()...)
+	pt = append(pt, s.myNonce.Hiding.Bytes()...)
+	pt = append(pt, s.myNonce.Binding.Bytes()...)
+	pt = binary.BigEndian.AppendUint32(pt, uint32(len(s.Message)))
+	pt = append(pt, s.Message...)
+	pt = binary.BigEndian.AppendUint32(pt, uint32(len(s.Participants)))
+	for _, p := range s.Participants {
+		id, pk := p.Bytes()
+		pt = append(pt, id...)
+		pt = append(pt, pk...)
+	}
+	defer clear(pt)
+
+	out := []byte{stateExportVersion}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	out = aead.Seal(out, nonce, pt, stateExportAD())
+
+	s.discardNonce()
+	return out, nil
+}
+
+// RestoreState decrypts a State exported with Export. The guard is consulted
+// before the nonce is released, so each export can only be restored once.
+func RestoreState(c Ciphersuite, blob, key []byte, mySecretShare *SecretShare, guard ReplayGuard) (*State, error) {
+	if mySecretShare == nil || guard == nil {
+		return nil, fmt.Errorf("a secret share and a replay guard are required")
+	}
+	aead, err := stateAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) < 1+aead.NonceSize()+aead.Overhead() || blob[0] != stateExportVersion {
+		return nil, fmt.Errorf("unsupported or truncated state export")
+	}
+	nonce := blob[1 : 1+aead.NonceSize()]
+	pt, err := aead.Open(nil, nonce, blob[1+aead.NonceSize():], stateExportAD())
+	if err != nil {
+		return nil, errors.New("state export could not be decrypted")
+	}
+	defer clear(pt)
+
+	r := &byteReader{b: pt}
+	idBytes := r.next(32)
+	groupKeyBytes := r.next(32)
+	hidingBytes := r.next(32)
+	bindingBytes := r.next(32)
+	msg := append([]byte{}, r.next(int(r.uint32()))...)
+	count := int(r.uint32())
+	var rawParticipants [][]byte
+	for i := 0; i < count && r.err == nil; i++ {
+		rawParticipants = append(rawParticipants, r.next(64))
+	}
+	if r.err != nil || len(r.b) != 0 {
+		return nil, fmt.Errorf("malformed state export")
+	}
+
+	myIdentifier, err := NewScalar().SetBytes(idBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !myIdentifier.Equal(mySecretShare.Identifier) {
+		return nil, fmt.Errorf("state export belongs to another participant")
+	}
+	groupKey, err := NewElement().SetBytes(groupKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	hiding, err := NewScalar().SetBytes(hidingBytes)
+	if err != nil {
+		return nil, err
+	}
+	binding, err := NewScalar().SetBytes(bindingBytes)
+	if err != nil {
+		return nil, err
+	}
+	participants := make([]*Participant, 0, len(rawParticipants))
+	for _, raw := range rawParticipants {
+		id, err := NewScalar().SetBytes(raw[:32])
+		if err != nil {
+			return nil, err
+		}
+		pk, err := NewElement().SetBytes(raw[32:])
+		if err != nil {
+			return nil, err
+		}
+		participants = append(participants, &Participant{Identifier: id, PublicKeyShare: pk})
+	}
+
+	s := NewState(c, participants, &GroupKey{Element: groupKey}, msg, myIdentifier, mySecretShare)
+	n := &Nonce{Hiding: hiding, Binding: binding}
+	commitment := &Commitment{
+		Identifier: myIdentifier,
+		Hiding:     NewElement().Mul(hiding, nil),
+		Binding:    NewElement().Mul(binding, nil),
+	}
+	if err := guard.Consume(commitment); err != nil {
+		n.Zeroize()
+		return nil, err
+	}
+	if err := s.SetNonce(n); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func stateAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != stateKeySize {
+		return nil, fmt.Errorf("state encryption key must be %d bytes", stateKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Binds the export to the format version and the ciphersuite.
+func stateExportAD() []byte {
+	return append([]byte(ContextString+"-state"), stateExportVersion)
+}
+
+// byteReader consumes a byte slice, remembering the first short read.
+type byteReader struct {
+	b   []byte
+	err error
+}
+
+func (r *byteReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b) {
+		r.err = fmt.Errorf("unexpected end of input")
+		return nil
+	}
+	out := r.b[:n]
+	r.b = r.b[n:]
+	return out
+}
+
+func (r *byteReader) uint32() uint32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
@@ -0,0 +1,306 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRefused matches every RefusalError with errors.Is.
+var ErrRefused = errors.New("signing refused by policy")
+
+// SigningRequest describes a signature share that a signer is about to
+// release, for a Policy to inspect.
+type SigningRequest struct {
+	// Signer is this participant's identifier
+	Signer *Scalar
+	// Message is the message being signed (empty for blind signing)
+	Message []byte
+	// Signers are the identifiers of the whole signing set
+	Signers []*Scalar
+	// GroupKey is the parent group key
+	GroupKey *GroupKey
+	// DerivationPath selects a child key, if set
+	DerivationPath [][]byte
+	// SessionID is the coordinator's session identifier, if any
+	SessionID []byte
+	// Randomizer re-randomizes the key, if set
+	Randomizer *Scalar
+	// AdaptorPoint is set for adaptor signatures
+	AdaptorPoint *Element
+	// Blind is set when the signer signs a requester's blinded challenge
+	Blind bool
+}
+
+// Policy decides whether a signer may release a signature share. It is
+// consulted by State.Sign before the nonce is touched, so a refusal leaves the
+// State able to sign a different request.
+type Policy interface {
+	Approve(req *SigningRequest) error
+}
+
+// PolicyFunc adapts a function to the Policy interface.
+type PolicyFunc func(req *SigningRequest) error
+
+func (f PolicyFunc) Approve(req *SigningRequest) error {
+	return f(req)
+}
+
+// Reserver is a Policy whose approvals use something up, such as the count
+// kept by QuotaPolicy. BatchState.SignPackages reserves approval of every
+// request first, and only commits the reservations once the whole batch has
+// been approved.
+type Reserver interface {
+	Policy
+	Reserve(req *SigningRequest) (*Reservation, error)
+}
+
+// Reservation is an approval held by a Reserver until it is committed or
+// cancelled.
+type Reservation struct {
+	commit func()
+	cancel func()
+}
+
+// Commit uses up what was reserved.
+func (r *Reservation) Commit() {
+	if r != nil && r.commit != nil {
+		r.commit()
+	}
+	r.done()
+}
+
+// Cancel gives back what was reserved.
+func (r *Reservation) Cancel() {
+	if r != nil && r.cancel != nil {
+		r.cancel()
+	}
+	r.done()
+}
+
+func (r *Reservation) done() {
+	if r != nil {
+		r.commit, r.cancel = nil, nil
+	}
+}
+
+// Reserve approval of req with p. A Policy that is not a Reserver uses nothing
+// up, so approving is enough.
+func reserve(p Policy, req *SigningRequest) (*Reservation, error) {
+	if r, ok := p.(Reserver); ok {
+		return r.Reserve(req)
+	}
+	if err := p.Approve(req); err != nil {
+		return nil, err
+	}
+	return &Reservation{}, nil
+}
+
+// RefusalError is returned by Sign when a policy refuses a request. Signers can
+// relay it to the coordinator, which can report who refused and why.
+type RefusalError struct {
+	// Signer is the identifier of the refusing participant
+	Signer *Scalar
+	// Policy names the policy that refused
+	Policy string
+	// Reason explains the refusal
+	Reason string
+}
+
+func (e *RefusalError) Error() string {
+	if e.Signer == nil {
+		return fmt.Sprintf("signing refused by policy %s: %s", e.Policy, e.Reason)
+	}
+	return fmt.Sprintf("participant %x refused to sign (policy %s): %s", e.Signer.Bytes(), e.Policy, e.Reason)
+}
+
+func (e *RefusalError) Is(target error) bool {
+	return target == ErrRefused
+}
+
+// Wrap any policy error into a RefusalError, filling in the signer.
+func asRefusal(err error, signer *Scalar) error {
+	var refusal *RefusalError
+	if !errors.As(err, &refusal) {
+		refusal = &RefusalError{Policy: "custom", Reason: err.Error()}
+	}
+	if refusal.Signer == nil {
+		refusal.Signer = signer
+	}
+	return refusal
+}
+
+// AllOf approves a request only if every policy does, checking them in order.
+func AllOf(policies ...Policy) Policy {
+	return allOf(policies)
+}
+
+type allOf []Policy
+
+func (a allOf) Approve(req *SigningRequest) error {
+	r, err := a.Reserve(req)
+	if err != nil {
+		return err
+	}
+	r.Commit()
+	return nil
+}
+
+// Reserve with every policy, so nothing is used up unless all of them approve.
+func (a allOf) Reserve(req *SigningRequest) (*Reservation, error) {
+	held := make([]*Reservation, 0, len(a))
+	for _, p := range a {
+		r, err := reserve(p, req)
+		if err != nil {
+			for _, r := range held {
+				r.Cancel()
+			}
+			return nil, err
+		}
+		held = append(held, r)
+	}
+	return &Reservation{
+		commit: func() {
+			for _, r := range held {
+				r.Commit()
+			}
+		},
+		cancel: func() {
+			for _, r := range held {
+				r.Cancel()
+			}
+		},
+	}, nil
+}
+
+// AllowPrefixes only approves messages starting with one of the prefixes.
+func AllowPrefixes(prefixes ...[]byte) Policy {
+	return PolicyFunc(func(req *SigningRequest) error {
+		for _, p := range prefixes {
+			if bytes.HasPrefix(req.Message, p) {
+				return nil
+			}
+		}
+		return &RefusalError{Policy: "prefix", Reason: "message does not start with an allowed prefix"}
+	})
+}
+
+// MaxMessageSize only approves messages of at most n bytes.
+func MaxMessageSize(n int) Policy {
+	return PolicyFunc(func(req *SigningRequest) error {
+		if len(req.Message) > n {
+			return &RefusalError{Policy: "size", Reason: fmt.Sprintf("message is %d bytes, limit is %d", len(req.Message), n)}
+		}
+		return nil
+	})
+}
+
+// RequireCoSigners only approves requests whose signing set includes all of
+// the given participants.
+func RequireCoSigners(ids ...*Scalar) Policy {
+	return PolicyFunc(func(req *SigningRequest) error {
+		for _, id := range ids {
+			found := false
+			for _, s := range req.Signers {
+				if s.Equal(id) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return &RefusalError{Policy: "cosigners", Reason: fmt.Sprintf("required co-signer %x is not in the signing set", id.Bytes())}
+			}
+		}
+		return nil
+	})
+}
+
+// QuotaPolicy approves at most a fixed number of signatures per key per UTC
+// day. Keys are told apart by their derivation path, so each child key has
+// its own quota. Counts are kept in memory.
+type QuotaPolicy struct {
+	limit int
+	now   func() time.Time
+
+	mu      sync.Mutex
+	day     string
+	count   map[string]int
+	pending map[string]int
+}
+
+// DailyQuota creates a QuotaPolicy. A nil clock means time.Now.
+func DailyQuota(limit int, now func() time.Time) *QuotaPolicy {
+	if now == nil {
+		now = time.Now
+	}
+	return &QuotaPolicy{limit: limit, now: now, count: make(map[string]int), pending: make(map[string]int)}
+}
+
+func (q *QuotaPolicy) Approve(req *SigningRequest) error {
+	r, err := q.Reserve(req)
+	if err != nil {
+		return err
+	}
+	r.Commit()
+	return nil
+}
+
+// Reserve holds one signature of the day's quota. Reserved signatures count
+// against the quota, but are only added to the day's count on Commit.
+func (q *QuotaPolicy) Reserve(req *SigningRequest) (*Reservation, error) {
+	key := req.GroupKey
+	if len(req.DerivationPath) > 0 {
+		key, _ = DeriveChildKey(req.GroupKey, req.DerivationPath...)
+	}
+	id := string(key.Bytes())
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	day := q.now().UTC().Format(time.DateOnly)
+	if day != q.day {
+		q.day = day
+		clear(q.count)
+		clear(q.pending)
+	}
+	if q.count[id]+q.pending[id] >= q.limit {
+		return nil, &RefusalError{Policy: "quota", Reason: fmt.Sprintf("daily limit of %d signatures reached", q.limit)}
+	}
+	q.pending[id]++
+	release := func(used bool) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		// Reservations from a previous day were cleared at midnight
+		if q.day != day {
+			return
+		}
+		q.pending[id]--
+		if used {
+			q.count[id]++
+		}
+	}
+	return &Reservation{
+		commit: func() { release(true) },
+		cancel: func() { release(false) },
+	}, nil
+}
+
+// Build the request a Policy sees for a commitment list.
+func (s *State) signingRequest(commitments []*Commitment) *SigningRequest {
+	signers := make([]*Scalar, 0, len(commitments))
+	for _, c := range commitments {
+		signers = append(signers, c.Identifier)
+	}
+	return &SigningRequest{
+		Signer:         s.MyIdentifier,
+		Message:        s.Message,
+		Signers:        signers,
+		GroupKey:       s.GroupKey,
+		DerivationPath: s.derivationPath,
+		SessionID:      s.SessionID,
+		Randomizer:     s.randomizer,
+		AdaptorPoint:   s.adaptorPoint,
+		Blind:          s.blindChallenge != nil,
+	}
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNonceNotFound is returned by a NonceStore that has never held a nonce for
+// the requested commitment.
+var ErrNonceNotFound = errors.New("no preprocessed nonce for this commitment")
+
+// NonceStore holds preprocessed nonces, indexed by their public commitment.
+//
+// Implementations must guarantee that Take returns a given nonce at most once,
+// even across process restarts, and should return ErrNonceReused (rather than
+// ErrNonceNotFound) for a commitment whose nonce was already taken.
+type NonceStore interface {
+	// Put stores a nonce under its commitment.
+	Put(commitment *Commitment, nonce *Nonce) error
+
+	// Take removes the nonce for a commitment from the store and returns it.
+	Take(commitment *Commitment) (*Nonce, error)
+}
+
+// Preprocess generates k nonces ahead of time, saves them to the store, and
+// returns the commitments to publish to the coordinator. The store takes
+// ownership of each nonce. Nonce randomness is drawn from r, or from
+// crypto/rand if r is nil.
+func Preprocess(c Ciphersuite, mySecretShare *SecretShare, k int, store NonceStore, r io.Reader) ([]*Commitment, error) {
+	if mySecretShare == nil {
+		return nil, fmt.Errorf("a secret share is required to preprocess nonces")
+	}
+	commitments := make([]*Commitment, 0, k)
+	for range k {
+		nonce, err := NewNonce(c, r, mySecretShare.Scalar)
+		if err != nil {
+			return nil, err
+		}
+		commitment := &Commitment{
+			Identifier: mySecretShare.Identifier,
+			Hiding:     NewElement().Mul(nonce.Hiding, nil),
+			Binding:    NewElement().Mul(nonce.Binding, nil),
+		}
+		if err := store.Put(commitment, nonce); err != nil {
+			return nil, err
+		}
+		commitments = append(commitments, commitment)
+	}
+	return commitments, nil
+}
+
+// LoadNonce finds this participant's commitment in the coordinator's
+// commitment list, and takes the matching preprocessed nonce out of the store.
+// Afterwards, Sign can be called directly without a Commit round.
+func (s *State) LoadNonce(store NonceStore, commitments []*Commitment) error {
+	var mine *Commitment
+	for _, c := range commitments {
+		if c.Identifier.Equal(s.MyIdentifier) {
+			mine = c
+			break
+		}
+	}
+	if mine == nil {
+		return fmt.Errorf("own commitment not found")
+	}
+	nonce, err := store.Take(mine)
+	if err != nil {
+		return err
+	}
+	if err := s.SetNonce(nonce); err != nil {
+		nonce.Zeroize()
+		return err
+	}
+	if !s.MyCommitment.Hiding.Equal(mine.Hiding) || !s.MyCommitment.Binding.Equal(mine.Binding) {
+		s.discardNonce()
+		s.MyCommitment = nil
+		return fmt.Errorf("stored nonce does not match commitment")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// The key type of an OpenSSH Ed25519 public key
+const sshEd25519 = "ssh-ed25519"
+
+// Decode a raw 32-byte Ed25519 public key as a group key. Non-canonical
+// encodings and the identity are rejected.
+func groupKeyFromRaw(b []byte) (*GroupKey, error) {
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key length: %d", len(b))
+	}
+	e, err := NewElement().SetBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(e.Bytes(), b) {
+		return nil, fmt.Errorf("non-canonical point encoding")
+	}
+	if e.IsIdentity() {
+		return nil, fmt.Errorf("group key is the identity")
+	}
+	return &GroupKey{Element: e}, nil
+}
+
+// Ed25519 returns the group key as a crypto/ed25519 public key, which verifies
+// every signature the group produces.
+func (gk *GroupKey) Ed25519() ed25519.PublicKey {
+	return ed25519.PublicKey(gk.Bytes())
+}
+
+// GroupKeyFromEd25519 converts a crypto/ed25519 public key to a group key.
+func GroupKeyFromEd25519(pub ed25519.PublicKey) (*GroupKey, error) {
+	return groupKeyFromRaw(pub)
+}
+
+// The SSH wire encoding of the key: the key type and the key, each with a
+// 4-byte length.
+func (gk *GroupKey) sshBlob() []byte {
+	var out []byte
+	out = binary.BigEndian.AppendUint32(out, uint32(len(sshEd25519)))
+	out = append(out, sshEd25519...)
+	out = binary.BigEndian.AppendUint32(out, ed25519.PublicKeySize)
+	return append(out, gk.Bytes()...)
+}
+
+// AuthorizedKey returns the group key as a line of an OpenSSH authorized_keys
+// file, ending in a newline. The comment is omitted if empty.
+func (gk *GroupKey) AuthorizedKey(comment string) []byte {
+	line := sshEd25519 + " " + base64.StdEncoding.EncodeToString(gk.sshBlob())
+	if comment = strings.TrimSpace(comment); comment != "" {
+		line += " " + comment
+	}
+	return []byte(line + "\n")
+}
+
+// GroupKeyFromAuthorizedKey parses an ssh-ed25519 line of an authorized_keys
+// file, and returns the key and its comment. Options before the key type are
+// skipped.
+func GroupKeyFromAuthorizedKey(line []byte) (*GroupKey, string, error) {
+	fields := strings.Fields(string(line))
+	for i, f := range fields {
+		if f != sshEd25519 {
+			continue
+		}
+		if i+1 == len(fields) {
+			break
+		}
+		blob, err := base64.StdEncoding.DecodeString(fields[i+1])
+		if err != nil {
+			return nil, "", err
+		}
+		r := &byteReader{b: blob}
+		keyType := r.next(int(r.uint32()))
+		key := r.next(int(r.uint32()))
+		if r.err != nil || len(r.b) != 0 || string(keyType) != sshEd25519 {
+			return nil, "", fmt.Errorf("malformed ssh-ed25519 key")
+		}
+		gk, err := groupKeyFromRaw(key)
+		if err != nil {
+			return nil, "", err
+		}
+		return gk, strings.Join(fields[i+2:], " "), nil
+	}
+	return nil, "", fmt.Errorf("no ssh-ed25519 key found")
+}
+
+// An OKP JSON Web Key (RFC 8037)
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	D   string `json:"d,omitempty"`
+}
+
+// JWK returns the group key as an OKP JSON Web Key (RFC 8037).
+func (gk *GroupKey) JWK() ([]byte, error) {
+	return json.Marshal(jwk{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(gk.Bytes())})
+}
+
+// GroupKeyFromJWK parses an OKP JSON Web Key (RFC 8037). Private keys are
+// rejected.
+func GroupKeyFromJWK(j []byte) (*GroupKey, error) {
+	var v jwk
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+	if v.Kty != "OKP" || v.Crv != "Ed25519" {
+		return nil, fmt.Errorf("not an Ed25519 JWK: kty %q, crv %q", v.Kty, v.Crv)
+	}
+	if v.D != "" {
+		return nil, fmt.Errorf("JWK contains a private key")
+	}
+	x, err := base64.RawURLEncoding.DecodeString(v.X)
+	if err != nil {
+		return nil, err
+	}
+	return groupKeyFromRaw(x)
+}
+
+// PKIX returns the group key as a DER-encoded SubjectPublicKeyInfo
+// (RFC 8410).
+func (gk *GroupKey) PKIX() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(gk.Ed25519())
+}
+
+// GroupKeyFromPKIX parses a DER-encoded SubjectPublicKeyInfo holding an
+// Ed25519 key.
+func GroupKeyFromPKIX(der []byte) (*GroupKey, error) {
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an Ed25519 public key: %T", pub)
+	}
+	return groupKeyFromRaw(key)
+}
+
+// PEM returns the SubjectPublicKeyInfo in a "PUBLIC KEY" PEM block.
+func (gk *GroupKey) PEM() ([]byte, error) {
+	der, err := gk.PKIX()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// GroupKeyFromPEM parses the first "PUBLIC KEY" PEM block.
+func GroupKeyFromPEM(b []byte) (*GroupKey, error) {
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			return nil, fmt.Errorf("no PUBLIC KEY block found")
+		}
+		if block.Type == "PUBLIC KEY" {
+			return GroupKeyFromPKIX(block.Bytes)
+		}
+	}
+}
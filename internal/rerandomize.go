@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+	"io"
+
+	"filippo.io/edwards25519"
+)
+
+// RandomizerSeedSize is the length of a randomizer seed.
+const RandomizerSeedSize = 32
+
+// NewRandomizerSeed generates a fresh randomizer seed. A nil reader means
+// crypto/rand.
+func NewRandomizerSeed(r io.Reader) ([]byte, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	seed := make([]byte, RandomizerSeedSize)
+	if _, err := io.ReadFull(r, seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// DeriveRandomizer computes the randomizer for a signing package from a seed.
+// The coordinator picks a fresh seed for each signature, and the randomizer is
+// derived from it and the rest of the package, commitments and message
+// included, so neither the coordinator nor a signer can grind it. Any
+// randomizer or seed already in the package is ignored.
+func DeriveRandomizer(seed []byte, pkg *SigningPackage) *Scalar {
+	unrandomized := *pkg
+	unrandomized.Randomizer = nil
+	unrandomized.RandomizerSeed = nil
+	h := sha512.New()
+	h.Write([]byte(ContextString))
+	h.Write([]byte("randomizer"))
+	h.Write(seed)
+	h.Write(unrandomized.Bytes())
+	s, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		// This should not happen
+		panic(err)
+	}
+	return &Scalar{s: s}
+}
+
+// Set the randomizer from the package's seed, for decoders. A package may
+// carry a seed or a bare randomizer, but not both.
+func (pkg *SigningPackage) deriveRandomizer() error {
+	if pkg.RandomizerSeed == nil {
+		return nil
+	}
+	if len(pkg.RandomizerSeed) != RandomizerSeedSize {
+		return fmt.Errorf("randomizer seed must be %d bytes", RandomizerSeedSize)
+	}
+	if pkg.Randomizer != nil {
+		return fmt.Errorf("signing package has both a randomizer and a seed")
+	}
+	pkg.Randomizer = DeriveRandomizer(pkg.RandomizerSeed, pkg)
+	return nil
+}
+
+// The randomizer a signer should use for a package: derived from the seed,
+// and matching the package's randomizer if it has one. A randomizer without
+// a seed is not bound to the package, and is refused.
+func (pkg *SigningPackage) checkedRandomizer() (*Scalar, error) {
+	if pkg.RandomizerSeed == nil {
+		if pkg.Randomizer != nil {
+			return nil, fmt.Errorf("randomizer is not bound to the signing package")
+		}
+		return nil, nil
+	}
+	if len(pkg.RandomizerSeed) != RandomizerSeedSize {
+		return nil, fmt.Errorf("randomizer seed must be %d bytes", RandomizerSeedSize)
+	}
+	alpha := DeriveRandomizer(pkg.RandomizerSeed, pkg)
+	if pkg.Randomizer != nil && !pkg.Randomizer.Equal(alpha) {
+		return nil, fmt.Errorf("randomizer does not match its seed")
+	}
+	return alpha, nil
+}
+
+// RandomizeGroupKey returns PK + [alpha]B, the key a re-randomized signature
+// verifies under.
+func RandomizeGroupKey(groupKey *GroupKey, randomizer *Scalar) *GroupKey {
+	tweak := NewElement().Mul(randomizer, nil)
+	return &GroupKey{Element: NewElement().Add(groupKey.Element, tweak)}
+}
+
+// SetRandomizer makes this State produce a re-randomized signature, as in
+// ZIP-312 (https://zips.z.cash/zip-0312). It must be called before Sign.
+//
+// The signature verifies under PK + [alpha]B rather than PK, so signatures
+// made with the same group key cannot be linked to each other, or to PK, by
+// anyone who does not learn alpha. Shares are computed as usual, except that
+// the binding factors and challenge are computed over the randomized key, and
+// the aggregator adds alpha * c to the response.
+func (s *State) SetRandomizer(randomizer *Scalar) error {
+	if s.groupCommitment != nil {
+		return fmt.Errorf("randomizer must be set before signing")
+	}
+	if s.randomizer != nil && !s.randomizer.Equal(randomizer) {
+		return fmt.Errorf("a different randomizer is already set")
+	}
+	s.randomizer = randomizer
+	return nil
+}
@@ -1,8 +1,10 @@
 package internal
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 )
 
 // Encode the ID and public share
@@ -151,3 +153,25 @@ func (sigshare *SignatureShare) EncodeJSON() ([]byte, error) {
 	}
 	return out, nil
 }
+
+// Decode a signature from its 64-byte encoding (R || z)
+//
+// Both halves must be canonical: z must be reduced mod L, and R must
+// re-encode to the same 32 bytes it was decoded from.
+func SignatureFromBytes(b []byte) (*Signature, error) {
+	if len(b) != 64 {
+		return nil, fmt.Errorf("invalid signature length: %d", len(b))
+	}
+	r, err := NewElement().SetBytes(b[:32])
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(r.Bytes(), b[:32]) {
+		return nil, fmt.Errorf("non-canonical encoding of R")
+	}
+	z, err := NewScalar().SetBytes(b[32:])
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{R: r, Z: z}, nil
+}
@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrOutOfOrder is returned when a round is called in the wrong phase.
+	ErrOutOfOrder = errors.New("round called out of order")
+
+	// ErrSessionExpired is returned once a session's context is done.
+	ErrSessionExpired = errors.New("signing session expired")
+)
+
+// Phase is the lifecycle stage of a Session.
+type Phase int
+
+const (
+	// PhaseNew is a session that has not done anything yet.
+	PhaseNew Phase = iota
+	// PhaseCommitted is a signer that has produced its commitment.
+	PhaseCommitted
+	// PhaseSigned is a signer that has produced its signature share, or a
+	// coordinator that has processed the commitment list.
+	PhaseSigned
+	// PhaseAggregated is a session that has produced the final signature.
+	PhaseAggregated
+	// PhaseFailed is a session that can no longer make progress, because it
+	// expired or its nonce was burned by a failed round.
+	PhaseFailed
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseNew:
+		return "new"
+	case PhaseCommitted:
+		return "committed"
+	case PhaseSigned:
+		return "signed"
+	case PhaseAggregated:
+		return "aggregated"
+	case PhaseFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+}
+
+// Session wraps a State with a lifecycle. Rounds must be called in order
+// (Commit, Sign, Aggregate), and a round called with a context that is done
+// fails the whole session, throwing away its nonce.
+//
+// A session without a secret share acts as a coordinator: it skips Commit, and
+// Sign only processes the commitment list.
+type Session struct {
+	mu    sync.Mutex
+	state *State
+	phase Phase
+}
+
+// NewSession starts a session for a State. The session takes over the State,
+// which should not be used directly afterwards.
+func NewSession(state *State) *Session {
+	return &Session{state: state, phase: PhaseNew}
+}
+
+// Phase returns the current phase of the session.
+func (s *Session) Phase() Phase {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.phase
+}
+
+// Commit performs the first round. Only valid in PhaseNew, for signers.
+func (s *Session) Commit(ctx context.Context) (*Commitment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "commit", PhaseNew); err != nil {
+		return nil, err
+	}
+	if s.state.MySecretShare == nil {
+		return nil, fmt.Errorf("%w: a coordinator session does not commit", ErrOutOfOrder)
+	}
+	commitment, err := s.state.Commit()
+	if err != nil {
+		return nil, err
+	}
+	s.phase = PhaseCommitted
+	return commitment, nil
+}
+
+// Sign performs the second round. Signers must have committed first;
+// coordinators call this directly from PhaseNew.
+func (s *Session) Sign(ctx context.Context, commitments []*Commitment) (*SignatureShare, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	want := PhaseCommitted
+	if s.state.MySecretShare == nil {
+		want = PhaseNew
+	}
+	if err := s.check(ctx, "sign", want); err != nil {
+		return nil, err
+	}
+	share, err := s.state.Sign(commitments)
+	if err != nil {
+		if s.state.MySecretShare != nil {
+			// The nonce is gone, so this session cannot recover.
+			s.phase = PhaseFailed
+		}
+		return nil, err
+	}
+	s.phase = PhaseSigned
+	return share, nil
+}
+
+// VerifySignatureShare checks a share against the processed commitment list.
+// Only valid in PhaseSigned.
+func (s *Session) VerifySignatureShare(ctx context.Context, share *SignatureShare) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "verify a signature share", PhaseSigned); err != nil {
+		return false, err
+	}
+	return s.state.VerifySignatureShare(share)
+}
+
+// Aggregate produces the final signature. Only valid in PhaseSigned.
+func (s *Session) Aggregate(ctx context.Context, shares []*SignatureShare) (*Signature, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(ctx, "aggregate", PhaseSigned); err != nil {
+		return nil, err
+	}
+	sig, err := s.state.Aggregate(shares)
+	if err != nil {
+		return nil, err
+	}
+	s.phase = PhaseAggregated
+	return sig, nil
+}
+
+// Must be called with the lock held.
+func (s *Session) check(ctx context.Context, op string, want Phase) error {
+	if err := ctx.Err(); err != nil {
+		s.expire()
+		return fmt.Errorf("%w: %w", ErrSessionExpired, err)
+	}
+	if s.phase != want {
+		return fmt.Errorf("%w: cannot %s in phase %s", ErrOutOfOrder, op, s.phase)
+	}
+	return nil
+}
+
+// Throw away the nonce of an expired session, so it can never be used.
+func (s *Session) expire() {
+	s.state.discardNonce()
+	s.phase = PhaseFailed
+}
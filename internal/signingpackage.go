@@ -0,0 +1,388 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SigningPackage is what the coordinator sends to every signer in the second
+// round: the message, the commitments of the chosen signing set, and an
+// optional session identifier.
+type SigningPackage struct {
+	SessionID   []byte
+	Message     []byte
+	Commitments []*Commitment
+	// Randomizer is set for re-randomized FROST, and nil otherwise. It is
+	// derived from RandomizerSeed and the rest of the package.
+	Randomizer *Scalar
+	// RandomizerSeed is the coordinator's seed for the randomizer
+	RandomizerSeed []byte
+	// DerivationPath selects a child key, and is empty for the group key
+	DerivationPath [][]byte
+	// AdaptorPoint is set for adaptor signatures, and nil otherwise
+	AdaptorPoint *Element
+	// BlindChallenge is set for blind signatures, and nil otherwise
+	BlindChallenge *Scalar
+}
+
+// An encoded signing package with any of the extensions after re-randomized
+// FROST starts with pkgExtendedMarker, which can never be the length of a
+// session ID, and a version byte. Other packages keep the original layout.
+const (
+	pkgExtendedMarker  = 0xffffffff
+	pkgExtendedVersion = 2
+)
+
+// Flags for the optional trailing fields of an extended signing package
+const (
+	pkgHasRandomizer = 1 << iota
+	pkgHasDerivationPath
+	pkgHasAdaptorPoint
+	pkgHasBlindChallenge
+	pkgHasRandomizerSeed
+)
+
+// Whether the package needs the extended encoding. A bare randomizer fits the
+// original layout, as a trailing 32-byte scalar.
+func (pkg *SigningPackage) extended() bool {
+	return pkg.RandomizerSeed != nil || len(pkg.DerivationPath) > 0 || pkg.AdaptorPoint != nil || pkg.BlindChallenge != nil
+}
+
+// Bytes returns the canonical encoding of the package. Commitments are sorted
+// by identifier, so two packages with the same contents always encode the
+// same way.
+//
+// A package with a randomizer seed, derivation path, adaptor point or blind
+// challenge is prefixed with a marker and version, and a flags byte and the
+// optional fields follow the commitments. Any other package is encoded as
+// before those extensions existed, with its randomizer, if any, at the end.
+func (pkg *SigningPackage) Bytes() []byte {
+	var out []byte
+	if pkg.extended() {
+		out = binary.BigEndian.AppendUint32(out, pkgExtendedMarker)
+		out = append(out, pkgExtendedVersion)
+	}
+	out = binary.BigEndian.AppendUint32(out, uint32(len(pkg.SessionID)))
+	out = append(out, pkg.SessionID...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(pkg.Message)))
+	out = append(out, pkg.Message...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(pkg.Commitments)))
+	for _, c := range pkg.sortedCommitments() {
+		id, hiding, binding := c.Bytes()
+		out = append(out, id...)
+		out = append(out, hiding...)
+		out = append(out, binding...)
+	}
+	if !pkg.extended() {
+		if pkg.Randomizer != nil {
+			out = append(out, pkg.Randomizer.Bytes()...)
+		}
+		return out
+	}
+
+	// The randomizer is derived from the seed, so only one is encoded
+	randomizer := pkg.Randomizer
+	if pkg.RandomizerSeed != nil {
+		randomizer = nil
+	}
+	var flags byte
+	if randomizer != nil {
+		flags |= pkgHasRandomizer
+	}
+	if len(pkg.DerivationPath) > 0 {
+		flags |= pkgHasDerivationPath
+	}
+	if pkg.AdaptorPoint != nil {
+		flags |= pkgHasAdaptorPoint
+	}
+	if pkg.BlindChallenge != nil {
+		flags |= pkgHasBlindChallenge
+	}
+	if pkg.RandomizerSeed != nil {
+		flags |= pkgHasRandomizerSeed
+	}
+	out = append(out, flags)
+	if randomizer != nil {
+		out = append(out, randomizer.Bytes()...)
+	}
+	if len(pkg.DerivationPath) > 0 {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(pkg.DerivationPath)))
+		for _, label := range pkg.DerivationPath {
+			out = binary.BigEndian.AppendUint32(out, uint32(len(label)))
+			out = append(out, label...)
+		}
+	}
+	if pkg.AdaptorPoint != nil {
+		out = append(out, pkg.AdaptorPoint.Bytes()...)
+	}
+	if pkg.BlindChallenge != nil {
+		out = append(out, pkg.BlindChallenge.Bytes()...)
+	}
+	if pkg.RandomizerSeed != nil {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(pkg.RandomizerSeed)))
+		out = append(out, pkg.RandomizerSeed...)
+	}
+	return out
+}
+
+// Decode a signing package from its canonical encoding
+func SigningPackageFromBytes(b []byte) (*SigningPackage, error) {
+	return readSigningPackage(&byteReader{b: b})
+}
+
+// Read a signing package that takes up the rest of r.
+func readSigningPackage(r *byteReader) (*SigningPackage, error) {
+	extended := len(r.b) >= 4 && binary.BigEndian.Uint32(r.b) == pkgExtendedMarker
+	if extended {
+		r.uint32()
+		if v := r.next(1); v != nil && v[0] != pkgExtendedVersion {
+			return nil, fmt.Errorf("unsupported signing package version %d", v[0])
+		}
+	}
+	pkg := &SigningPackage{}
+	if sessionID := r.next(int(r.uint32())); len(sessionID) > 0 {
+		pkg.SessionID = append([]byte{}, sessionID...)
+	}
+	pkg.Message = append([]byte{}, r.next(int(r.uint32()))...)
+	n := r.count(96)
+	pkg.Commitments = make([]*Commitment, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		com := &Commitment{Identifier: r.identifier(), Hiding: r.element(), Binding: r.element()}
+		if r.err != nil {
+			break
+		}
+		if err := ValidateCommitment(com); err != nil {
+			return nil, err
+		}
+		if i > 0 && bytes.Compare(pkg.Commitments[i-1].Identifier.Bytes(), com.Identifier.Bytes()) >= 0 {
+			return nil, fmt.Errorf("signing package commitments are not in canonical order")
+		}
+		pkg.Commitments = append(pkg.Commitments, com)
+	}
+	var flags byte
+	if extended {
+		if f := r.next(1); f != nil {
+			flags = f[0]
+		}
+	} else if r.err == nil && len(r.b) == 32 {
+		flags = pkgHasRandomizer
+	}
+	if flags&^(pkgHasRandomizer|pkgHasDerivationPath|pkgHasAdaptorPoint|pkgHasBlindChallenge|pkgHasRandomizerSeed) != 0 {
+		return nil, fmt.Errorf("unknown signing package flags")
+	}
+	if flags&pkgHasRandomizer != 0 {
+		pkg.Randomizer = r.scalar()
+	}
+	if flags&pkgHasDerivationPath != 0 {
+		labels := int(r.uint32())
+		for i := 0; i < labels && r.err == nil; i++ {
+			pkg.DerivationPath = append(pkg.DerivationPath, append([]byte{}, r.next(int(r.uint32()))...))
+		}
+		if r.err == nil && len(pkg.DerivationPath) == 0 {
+			r.err = fmt.Errorf("empty derivation path")
+		}
+	}
+	if flags&pkgHasAdaptorPoint != 0 {
+		pkg.AdaptorPoint = r.element()
+	}
+	if flags&pkgHasBlindChallenge != 0 {
+		pkg.BlindChallenge = r.scalar()
+	}
+	if flags&pkgHasRandomizerSeed != 0 {
+		pkg.RandomizerSeed = append([]byte{}, r.next(int(r.uint32()))...)
+	}
+	if r.err != nil || len(r.b) != 0 {
+		return nil, fmt.Errorf("malformed signing package")
+	}
+	if extended && flags&^pkgHasRandomizer == 0 {
+		return nil, fmt.Errorf("non-canonical signing package encoding")
+	}
+	if err := pkg.deriveRandomizer(); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// Hash returns a digest of the canonical encoding. Signers can compare it with
+// each other to detect a coordinator sending different packages to different
+// signers.
+func (pkg *SigningPackage) Hash() []byte {
+	h := sha512.New()
+	h.Write([]byte(ContextString))
+	h.Write([]byte("pkg"))
+	h.Write(pkg.Bytes())
+	return h.Sum(nil)
+}
+
+// Encode a signing package as JSON
+func (pkg *SigningPackage) EncodeJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Package string `json:"p"`
+	}{
+		Package: base64.URLEncoding.EncodeToString(pkg.Bytes()),
+	})
+}
+
+// Deserialize a signing package from a JSON-encoded byte slice
+func SigningPackageFromJSON(j []byte) (*SigningPackage, error) {
+	var v struct {
+		Package string `json:"p"`
+	}
+	err := json.Unmarshal(j, &v)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.URLEncoding.DecodeString(v.Package)
+	if err != nil {
+		return nil, err
+	}
+	return SigningPackageFromBytes(raw)
+}
+
+// Validate checks that every commitment in the package comes from a distinct,
+// known participant, and that none of them contain the identity element.
+func (pkg *SigningPackage) Validate(participants []*Participant) error {
+	if len(pkg.Commitments) == 0 {
+		return fmt.Errorf("signing package has no commitments")
+	}
+	for i, c := range pkg.Commitments {
+		if err := ValidateCommitment(c); err != nil {
+			return err
+		}
+		known := false
+		for _, p := range participants {
+			if p.Identifier.Equal(c.Identifier) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("signing package contains an unknown participant")
+		}
+		for _, other := range pkg.Commitments[:i] {
+			if other.Identifier.Equal(c.Identifier) {
+				return fmt.Errorf("signing package lists a participant twice")
+			}
+		}
+	}
+	return nil
+}
+
+// SignPackage checks a signing package from the coordinator and, if it is
+// acceptable, performs the second round with it.
+//
+// The package must be for this State's message, must pass Validate, and must
+// contain this participant's own commitment unchanged. A package carries a
+// blind challenge exactly when this State comes from a BlindSigner. Any
+// randomizer, derivation path, adaptor point or blind challenge in the package
+// is applied to this State. If any check fails, the nonce is left untouched,
+// except that a blind session ends when the Policy refuses it.
+func (s *State) SignPackage(pkg *SigningPackage) (*SignatureShare, error) {
+	if err := s.checkPackage(pkg); err != nil {
+		return nil, err
+	}
+	r, err := s.reservePackage(pkg)
+	if err != nil {
+		return nil, s.refused(err)
+	}
+	r.Commit()
+	if err := s.applyPackage(pkg); err != nil {
+		return nil, err
+	}
+	return s.sign(pkg.Commitments)
+}
+
+// Apply the package to a copy of this State, and reserve the Policy's approval
+// of the result, so a refusal leaves this State exactly as it was.
+func (s *State) reservePackage(pkg *SigningPackage) (*Reservation, error) {
+	preview := *s
+	if err := preview.applyPackage(pkg); err != nil {
+		return nil, err
+	}
+	return preview.reserve(pkg.Commitments)
+}
+
+// The checks behind SignPackage, which never touch the nonce.
+func (s *State) checkPackage(pkg *SigningPackage) error {
+	if s.MySecretShare == nil || s.MyCommitment == nil {
+		return fmt.Errorf("only a committed signer can sign a package")
+	}
+	if pkg == nil {
+		return fmt.Errorf("missing signing package")
+	}
+	if subtle.ConstantTimeCompare(pkg.Message, s.Message) != 1 {
+		return fmt.Errorf("signing package is for a different message")
+	}
+	if err := pkg.Validate(s.Participants); err != nil {
+		return err
+	}
+	if pkg.BlindChallenge != nil && s.blind == nil {
+		return fmt.Errorf("signing package has a blind challenge, but this signer has not opted in to blind signing")
+	}
+	if pkg.BlindChallenge == nil && s.blind != nil {
+		return fmt.Errorf("blind signer received a signing package without a blind challenge")
+	}
+	mine := pkg.commitment(s.MyIdentifier)
+	if mine == nil {
+		return fmt.Errorf("own commitment not found in signing package")
+	}
+	if !mine.Hiding.Equal(s.MyCommitment.Hiding) || !mine.Binding.Equal(s.MyCommitment.Binding) {
+		return fmt.Errorf("own commitment was altered in signing package")
+	}
+	return nil
+}
+
+// Apply the package's optional fields to this State.
+func (s *State) applyPackage(pkg *SigningPackage) error {
+	if len(pkg.SessionID) > 0 {
+		s.SessionID = pkg.SessionID
+	}
+	alpha, err := pkg.checkedRandomizer()
+	if err != nil {
+		return err
+	}
+	if alpha != nil {
+		if err := s.SetRandomizer(alpha); err != nil {
+			return err
+		}
+	}
+	if len(pkg.DerivationPath) > 0 {
+		if err := s.SetDerivationPath(pkg.DerivationPath...); err != nil {
+			return err
+		}
+	}
+	if pkg.AdaptorPoint != nil {
+		if err := s.SetAdaptorPoint(pkg.AdaptorPoint); err != nil {
+			return err
+		}
+	}
+	if pkg.BlindChallenge != nil {
+		if err := s.SetBlindChallenge(pkg.BlindChallenge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pkg *SigningPackage) commitment(id *Scalar) *Commitment {
+	for _, c := range pkg.Commitments {
+		if c.Identifier.Equal(id) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (pkg *SigningPackage) sortedCommitments() []*Commitment {
+	sorted := append([]*Commitment{}, pkg.Commitments...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Identifier.Bytes(), sorted[j].Identifier.Bytes()) < 0
+	})
+	return sorted
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"io"
 	"math/big"
+	"sync/atomic"
 
 	"filippo.io/edwards25519"
 )
@@ -44,9 +45,13 @@ type Commitment struct {
 
 // Nonce is a pair of scalar values generated by a participant in the first
 // round of the signing protocol.
+//
+// A Nonce may be attached to at most one State, and is zeroized once that
+// State has used it to produce a signature share.
 type Nonce struct {
 	Hiding  *Scalar
 	Binding *Scalar
+	claimed atomic.Bool
 }
 
 // SignatureShare is a share of the final signature, produced by a participant
@@ -95,8 +100,16 @@ func NewScalar() *Scalar {
 
 // Generate a random scalar
 func RandomScalar() (*Scalar, error) {
+	return RandomScalarFrom(rand.Reader)
+}
+
+// Generate a random scalar from 64 bytes of r. A nil reader means crypto/rand.
+func RandomScalarFrom(r io.Reader) (*Scalar, error) {
+	if r == nil {
+		r = rand.Reader
+	}
 	b := make([]byte, 64)
-	_, err := io.ReadFull(rand.Reader, b)
+	_, err := io.ReadFull(r, b)
 	if err != nil {
 		return nil, err
 	}
@@ -270,3 +283,47 @@ func (s *Scalar) BigInt() *big.Int {
 	}
 	return new(big.Int).SetBytes(b)
 }
+
+// Negate sets the element to the negation of p.
+func (e *Element) Negate(p *Element) *Element {
+	e.e.Negate(p.e)
+	return e
+}
+
+// MultByCofactor multiplies an element by the cofactor (8) of the curve.
+func (e *Element) MultByCofactor(p *Element) *Element {
+	e.e.MultByCofactor(p.e)
+	return e
+}
+
+// IsIdentity returns true if the element is the identity element.
+func (e *Element) IsIdentity() bool {
+	return e.e.Equal(edwards25519.NewIdentityPoint()) == 1
+}
+
+// Negate sets the scalar to the negation of a.
+func (s *Scalar) Negate(a *Scalar) *Scalar {
+	s.s.Negate(a.s)
+	return s
+}
+
+// Zeroize overwrites the scalar with zero.
+func (s *Scalar) Zeroize() {
+	s.s.Set(edwards25519.NewScalar())
+}
+
+// Zeroize overwrites both nonce scalars with zero.
+func (n *Nonce) Zeroize() {
+	if n.Hiding != nil {
+		n.Hiding.Zeroize()
+	}
+	if n.Binding != nil {
+		n.Binding.Zeroize()
+	}
+}
+
+// Mark the nonce as belonging to a State. Returns false if it was already
+// claimed.
+func (n *Nonce) claim() bool {
+	return n.claimed.CompareAndSwap(false, true)
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"fmt"
+)
+
+// VerifyMode selects the group equation used to verify a signature.
+type VerifyMode int
+
+const (
+	// VerifyCofactored checks [8][z]B = [8]R + [8][c]PK, as mandated by
+	// RFC 9591, section 6.1.
+	VerifyCofactored VerifyMode = iota
+	// VerifyCofactorless checks [z]B = R + [c]PK, which is what
+	// crypto/ed25519.Verify does.
+	VerifyCofactorless
+)
+
+// Verify checks a signature against a group public key.
+//
+// Signature.Z is always a canonical scalar, since Scalar.SetBytes rejects
+// non-canonical encodings, so the S < L check from RFC 8032 is implied.
+func Verify(c Ciphersuite, groupKey *Element, msg []byte, sig *Signature, mode VerifyMode) (bool, error) {
+	if groupKey == nil || sig == nil || sig.R == nil || sig.Z == nil {
+		return false, fmt.Errorf("missing signature or group key")
+	}
+	challenge := ComputeChallenge(c, sig.R, groupKey, msg)
+
+	// R' = [z]B + [c](-PK). Negating the point rather than the challenge
+	// matters when PK has a torsion component, since L is not 0 mod 8.
+	negKey := NewElement().Negate(groupKey)
+	rPrime := NewElement()
+	rPrime.e.VarTimeDoubleScalarBaseMult(challenge.s, negKey.e, sig.Z.s)
+
+	switch mode {
+	case VerifyCofactored:
+		diff := NewElement().Sub(rPrime, sig.R)
+		return NewElement().MultByCofactor(diff).IsIdentity(), nil
+	case VerifyCofactorless:
+		return rPrime.Equal(sig.R), nil
+	default:
+		return false, fmt.Errorf("unknown verification mode")
+	}
+}
@@ -0,0 +1,448 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// WireVersion is the version of the binary encoding. Every encoding
+	// starts with a 3-byte header: this version, the ciphersuite ID and a
+	// type tag.
+	WireVersion = 1
+
+	// SuiteEd25519Sha512 identifies FROST(Ed25519, SHA-512) in encodings.
+	SuiteEd25519Sha512 = 1
+
+	wireHeaderSize = 3
+)
+
+// WireType is the type tag of a binary encoding.
+type WireType byte
+
+const (
+	WireScalar WireType = iota + 1
+	WireElement
+	WireGroupKey
+	WireParticipant
+	WireSecretShare
+	WireCommitment
+	WireSignatureShare
+	WireSignature
+	WireVSSCommitment
+	WireKeygenOutput
+	WireSigningPackage
+	WireMessage
+)
+
+func (t WireType) String() string {
+	switch t {
+	case WireScalar:
+		return "scalar"
+	case WireElement:
+		return "element"
+	case WireGroupKey:
+		return "group key"
+	case WireParticipant:
+		return "participant"
+	case WireSecretShare:
+		return "secret share"
+	case WireCommitment:
+		return "commitment"
+	case WireSignatureShare:
+		return "signature share"
+	case WireSignature:
+		return "signature"
+	case WireVSSCommitment:
+		return "VSS commitment"
+	case WireKeygenOutput:
+		return "keygen output"
+	case WireSigningPackage:
+		return "signing package"
+	case WireMessage:
+		return "message"
+	default:
+		return fmt.Sprintf("WireType(%d)", byte(t))
+	}
+}
+
+func wireHeader(t WireType) []byte {
+	return []byte{WireVersion, SuiteEd25519Sha512, byte(t)}
+}
+
+// Check the header of an encoding and return a reader over its fields.
+func openWire(b []byte, t WireType) (*byteReader, error) {
+	if len(b) < wireHeaderSize {
+		return nil, fmt.Errorf("%s encoding too short", t)
+	}
+	if b[0] != WireVersion {
+		return nil, fmt.Errorf("unsupported encoding version %d", b[0])
+	}
+	if b[1] != SuiteEd25519Sha512 {
+		return nil, fmt.Errorf("unsupported ciphersuite %d", b[1])
+	}
+	if WireType(b[2]) != t {
+		return nil, fmt.Errorf("expected %s encoding, got %s", t, WireType(b[2]))
+	}
+	return &byteReader{b: b[wireHeaderSize:]}, nil
+}
+
+// Check that a reader was consumed exactly.
+func closeWire(r *byteReader, t WireType) error {
+	if r.err != nil || len(r.b) != 0 {
+		return fmt.Errorf("malformed %s encoding", t)
+	}
+	return nil
+}
+
+// Read a canonically encoded scalar.
+func (r *byteReader) scalar() *Scalar {
+	b := r.next(32)
+	if b == nil {
+		return nil
+	}
+	s, err := NewScalar().SetBytes(b)
+	if err != nil {
+		r.err = err
+		return nil
+	}
+	return s
+}
+
+// Read a non-zero identifier.
+func (r *byteReader) identifier() *Scalar {
+	s := r.scalar()
+	if s != nil && s.Equal(NewScalar()) {
+		r.err = fmt.Errorf("zero identifier")
+		return nil
+	}
+	return s
+}
+
+// Read a canonically encoded point.
+func (r *byteReader) element() *Element {
+	b := r.next(32)
+	if b == nil {
+		return nil
+	}
+	e, err := NewElement().SetBytes(b)
+	if err != nil {
+		r.err = err
+		return nil
+	}
+	if !bytes.Equal(e.Bytes(), b) {
+		r.err = fmt.Errorf("non-canonical point encoding")
+		return nil
+	}
+	return e
+}
+
+// Read a list length, refusing counts that cannot fit in the rest of the
+// input at size bytes per item.
+func (r *byteReader) count(size int) int {
+	n := r.uint32()
+	if r.err == nil && uint64(n)*uint64(size) > uint64(len(r.b)) {
+		r.err = fmt.Errorf("list length exceeds input")
+		return 0
+	}
+	return int(n)
+}
+
+// EncodeBinary encodes a scalar.
+func (s *Scalar) EncodeBinary() []byte {
+	return append(wireHeader(WireScalar), s.Bytes()...)
+}
+
+// ScalarFromBinary decodes a scalar.
+func ScalarFromBinary(b []byte) (*Scalar, error) {
+	r, err := openWire(b, WireScalar)
+	if err != nil {
+		return nil, err
+	}
+	s := r.scalar()
+	if err := closeWire(r, WireScalar); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// EncodeBinary encodes a point.
+func (e *Element) EncodeBinary() []byte {
+	return append(wireHeader(WireElement), e.Bytes()...)
+}
+
+// ElementFromBinary decodes a point.
+func ElementFromBinary(b []byte) (*Element, error) {
+	r, err := openWire(b, WireElement)
+	if err != nil {
+		return nil, err
+	}
+	e := r.element()
+	if err := closeWire(r, WireElement); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// EncodeBinary encodes a group key.
+func (gk *GroupKey) EncodeBinary() []byte {
+	return append(wireHeader(WireGroupKey), gk.Bytes()...)
+}
+
+// GroupKeyFromBinary decodes a group key. The identity is rejected.
+func GroupKeyFromBinary(b []byte) (*GroupKey, error) {
+	r, err := openWire(b, WireGroupKey)
+	if err != nil {
+		return nil, err
+	}
+	e := r.element()
+	if err := closeWire(r, WireGroupKey); err != nil {
+		return nil, err
+	}
+	if e.IsIdentity() {
+		return nil, fmt.Errorf("group key is the identity")
+	}
+	return &GroupKey{Element: e}, nil
+}
+
+// EncodeBinary encodes a participant's identifier and public key share.
+func (p *Participant) EncodeBinary() []byte {
+	out := wireHeader(WireParticipant)
+	out = append(out, p.Identifier.Bytes()...)
+	return append(out, p.PublicKeyShare.Bytes()...)
+}
+
+// ParticipantFromBinary decodes a participant.
+func ParticipantFromBinary(b []byte) (*Participant, error) {
+	r, err := openWire(b, WireParticipant)
+	if err != nil {
+		return nil, err
+	}
+	p := r.participant()
+	if err := closeWire(r, WireParticipant); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *byteReader) participant() *Participant {
+	return &Participant{Identifier: r.identifier(), PublicKeyShare: r.element()}
+}
+
+// EncodeBinary encodes a secret share. The output is secret.
+func (ss *SecretShare) EncodeBinary() []byte {
+	out := wireHeader(WireSecretShare)
+	out = append(out, ss.Identifier.Bytes()...)
+	return append(out, ss.Scalar.Bytes()...)
+}
+
+// SecretShareFromBinary decodes a secret share.
+func SecretShareFromBinary(b []byte) (*SecretShare, error) {
+	r, err := openWire(b, WireSecretShare)
+	if err != nil {
+		return nil, err
+	}
+	ss := r.secretShare()
+	if err := closeWire(r, WireSecretShare); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+func (r *byteReader) secretShare() *SecretShare {
+	return &SecretShare{Identifier: r.identifier(), Scalar: r.scalar()}
+}
+
+// EncodeBinary encodes a commitment.
+func (com *Commitment) EncodeBinary() []byte {
+	out := wireHeader(WireCommitment)
+	out = append(out, com.Identifier.Bytes()...)
+	out = append(out, com.Hiding.Bytes()...)
+	return append(out, com.Binding.Bytes()...)
+}
+
+// CommitmentFromBinary decodes a commitment. Identity elements are rejected.
+func CommitmentFromBinary(b []byte) (*Commitment, error) {
+	r, err := openWire(b, WireCommitment)
+	if err != nil {
+		return nil, err
+	}
+	com := &Commitment{Identifier: r.identifier(), Hiding: r.element(), Binding: r.element()}
+	if err := closeWire(r, WireCommitment); err != nil {
+		return nil, err
+	}
+	if err := ValidateCommitment(com); err != nil {
+		return nil, err
+	}
+	return com, nil
+}
+
+// EncodeBinary encodes a signature share.
+func (sigshare *SignatureShare) EncodeBinary() []byte {
+	out := wireHeader(WireSignatureShare)
+	out = append(out, sigshare.Identifier.Bytes()...)
+	return append(out, sigshare.Share.Bytes()...)
+}
+
+// SignatureShareFromBinary decodes a signature share.
+func SignatureShareFromBinary(b []byte) (*SignatureShare, error) {
+	r, err := openWire(b, WireSignatureShare)
+	if err != nil {
+		return nil, err
+	}
+	share := &SignatureShare{Identifier: r.identifier(), Share: r.scalar()}
+	if err := closeWire(r, WireSignatureShare); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// EncodeBinary encodes a signature.
+func (s *Signature) EncodeBinary() []byte {
+	return append(wireHeader(WireSignature), s.Bytes()...)
+}
+
+// SignatureFromBinary decodes a signature.
+func SignatureFromBinary(b []byte) (*Signature, error) {
+	r, err := openWire(b, WireSignature)
+	if err != nil {
+		return nil, err
+	}
+	sig := &Signature{R: r.element(), Z: r.scalar()}
+	if err := closeWire(r, WireSignature); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// EncodeVSSCommitment encodes the VSS commitment from key generation.
+func EncodeVSSCommitment(vss []*Element) []byte {
+	out := wireHeader(WireVSSCommitment)
+	return appendElements(out, vss)
+}
+
+// VSSCommitmentFromBinary decodes a VSS commitment.
+func VSSCommitmentFromBinary(b []byte) ([]*Element, error) {
+	r, err := openWire(b, WireVSSCommitment)
+	if err != nil {
+		return nil, err
+	}
+	vss := r.elements()
+	if err := closeWire(r, WireVSSCommitment); err != nil {
+		return nil, err
+	}
+	if len(vss) == 0 {
+		return nil, fmt.Errorf("empty VSS commitment")
+	}
+	return vss, nil
+}
+
+func appendElements(out []byte, elements []*Element) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(elements)))
+	for _, e := range elements {
+		out = append(out, e.Bytes()...)
+	}
+	return out
+}
+
+func (r *byteReader) elements() []*Element {
+	n := r.count(32)
+	out := make([]*Element, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		out = append(out, r.element())
+	}
+	return out
+}
+
+// EncodeKeygenOutput encodes the result of key generation: the secret
+// shares, the participants, the group key and the VSS commitment, in that
+// order. The output contains every secret share.
+func EncodeKeygenOutput(shares []*SecretShare, participants []*Participant, groupKey *GroupKey, vss []*Element) []byte {
+	out := wireHeader(WireKeygenOutput)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(shares)))
+	for _, ss := range shares {
+		out = append(out, ss.Identifier.Bytes()...)
+		out = append(out, ss.Scalar.Bytes()...)
+	}
+	out = binary.BigEndian.AppendUint32(out, uint32(len(participants)))
+	for _, p := range participants {
+		out = append(out, p.Identifier.Bytes()...)
+		out = append(out, p.PublicKeyShare.Bytes()...)
+	}
+	out = append(out, groupKey.Bytes()...)
+	return appendElements(out, vss)
+}
+
+// KeygenOutputFromBinary decodes the result of key generation.
+func KeygenOutputFromBinary(b []byte) ([]*SecretShare, []*Participant, *GroupKey, []*Element, error) {
+	r, err := openWire(b, WireKeygenOutput)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	n := r.count(64)
+	shares := make([]*SecretShare, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		shares = append(shares, r.secretShare())
+	}
+	n = r.count(64)
+	participants := make([]*Participant, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		participants = append(participants, r.participant())
+	}
+	gk := &GroupKey{Element: r.element()}
+	vss := r.elements()
+	if err := closeWire(r, WireKeygenOutput); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := CheckKeygenOutput(shares, participants, gk, vss); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return shares, participants, gk, vss, nil
+}
+
+// CheckKeygenOutput checks decoded key generation output: every entry must be
+// present, the group key must not be the identity, and the VSS commitment must
+// not be empty.
+func CheckKeygenOutput(shares []*SecretShare, participants []*Participant, groupKey *GroupKey, vss []*Element) error {
+	for _, ss := range shares {
+		if ss == nil || ss.Identifier == nil || ss.Scalar == nil {
+			return fmt.Errorf("incomplete secret share")
+		}
+	}
+	for _, p := range participants {
+		if p == nil || p.Identifier == nil || p.PublicKeyShare == nil {
+			return fmt.Errorf("incomplete participant")
+		}
+	}
+	if groupKey == nil || groupKey.Element == nil {
+		return fmt.Errorf("missing group key")
+	}
+	if groupKey.Element.IsIdentity() {
+		return fmt.Errorf("group key is the identity element")
+	}
+	if len(vss) == 0 {
+		return fmt.Errorf("empty VSS commitment")
+	}
+	for _, e := range vss {
+		if e == nil {
+			return fmt.Errorf("incomplete VSS commitment")
+		}
+	}
+	return nil
+}
+
+// EncodeBinary encodes a signing package: the header followed by Bytes.
+func (pkg *SigningPackage) EncodeBinary() []byte {
+	return append(wireHeader(WireSigningPackage), pkg.Bytes()...)
+}
+
+// SigningPackageFromBinary decodes a signing package. Commitments must be
+// valid and in canonical order.
+func SigningPackageFromBinary(b []byte) (*SigningPackage, error) {
+	r, err := openWire(b, WireSigningPackage)
+	if err != nil {
+		return nil, err
+	}
+	return readSigningPackage(r)
+}
@@ -1,6 +1,8 @@
 package frost
 
 import (
+	"encoding/json"
+
 	"github.com/soatok/frost/internal"
 )
 
@@ -29,3 +31,92 @@ func (ko *KeygenOutput) Commitments(index int) [][]byte {
 	}
 	return out
 }
+
+// Encode the whole key generation output in binary. The output contains every
+// participant's secret share.
+func (ko *KeygenOutput) EncodeBinary() []byte {
+	return internal.EncodeKeygenOutput(ko.ParticipantPrivateKeys, ko.Participants, ko.GroupPublicKey, ko.VssCommitment)
+}
+
+// Decode key generation output from its binary encoding
+func KeygenOutputFromBinary(b []byte) (*KeygenOutput, error) {
+	shares, participants, gk, vss, err := internal.KeygenOutputFromBinary(b)
+	if err != nil {
+		return nil, err
+	}
+	return &KeygenOutput{
+		ParticipantPrivateKeys: shares,
+		Participants:           participants,
+		GroupPublicKey:         gk,
+		VssCommitment:          vss,
+	}, nil
+}
+
+// Encode the whole key generation output as CBOR. The output contains every
+// participant's secret share.
+func (ko *KeygenOutput) EncodeCBOR() []byte {
+	return internal.EncodeKeygenOutputCBOR(ko.ParticipantPrivateKeys, ko.Participants, ko.GroupPublicKey, ko.VssCommitment)
+}
+
+// Decode key generation output from CBOR
+func KeygenOutputFromCBOR(b []byte) (*KeygenOutput, error) {
+	shares, participants, gk, vss, err := internal.KeygenOutputFromCBOR(b)
+	if err != nil {
+		return nil, err
+	}
+	return &KeygenOutput{
+		ParticipantPrivateKeys: shares,
+		Participants:           participants,
+		GroupPublicKey:         gk,
+		VssCommitment:          vss,
+	}, nil
+}
+
+func (ko *KeygenOutput) MarshalBinary() ([]byte, error) {
+	return ko.EncodeBinary(), nil
+}
+
+func (ko *KeygenOutput) UnmarshalBinary(b []byte) error {
+	v, err := KeygenOutputFromBinary(b)
+	if err != nil {
+		return err
+	}
+	*ko = *v
+	return nil
+}
+
+// The binary encoding in base64url
+func (ko *KeygenOutput) MarshalText() ([]byte, error) {
+	return internal.MarshalText(ko.MarshalBinary())
+}
+
+func (ko *KeygenOutput) UnmarshalText(text []byte) error {
+	b, err := internal.UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+	return ko.UnmarshalBinary(b)
+}
+
+type keygenOutputJSON struct {
+	ParticipantPrivateKeys []*internal.SecretShare `json:"secret_shares"`
+	Participants           []*internal.Participant `json:"participants"`
+	GroupPublicKey         *internal.GroupKey      `json:"group_key"`
+	VssCommitment          []*internal.Element     `json:"vss_commitment"`
+}
+
+func (ko *KeygenOutput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keygenOutputJSON(*ko))
+}
+
+func (ko *KeygenOutput) UnmarshalJSON(j []byte) error {
+	var v keygenOutputJSON
+	if err := json.Unmarshal(j, &v); err != nil {
+		return err
+	}
+	if err := internal.CheckKeygenOutput(v.ParticipantPrivateKeys, v.Participants, v.GroupPublicKey, v.VssCommitment); err != nil {
+		return err
+	}
+	*ko = KeygenOutput(v)
+	return nil
+}
@@ -0,0 +1,167 @@
+package frost_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/stretchr/testify/require"
+)
+
+type marshaler interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+	encoding.TextMarshaler
+	encoding.TextUnmarshaler
+	json.Marshaler
+	json.Unmarshaler
+}
+
+var (
+	_ marshaler = (*frost.Scalar)(nil)
+	_ marshaler = (*frost.Element)(nil)
+	_ marshaler = (*frost.GroupKey)(nil)
+	_ marshaler = (*frost.Participant)(nil)
+	_ marshaler = (*frost.SecretShare)(nil)
+	_ marshaler = (*frost.Commitment)(nil)
+	_ marshaler = (*frost.SignatureShare)(nil)
+	_ marshaler = (*frost.Signature)(nil)
+	_ marshaler = (*frost.KeygenOutput)(nil)
+)
+
+func TestMarshalEmbedded(t *testing.T) {
+	msg := []byte("embedded")
+	keygen, sig := thresholdSign(t, msg)
+	state := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[1])
+	com, err := state.Commit()
+	require.NoError(t, err)
+
+	type record struct {
+		Keygen    *frost.KeygenOutput   `json:"keygen"`
+		Key       *frost.GroupKey       `json:"key"`
+		Signer    *frost.Participant    `json:"signer"`
+		Secret    *frost.SecretShare    `json:"secret"`
+		Nonce     *frost.Commitment     `json:"nonce"`
+		Share     *frost.SignatureShare `json:"share"`
+		Signature *frost.Signature      `json:"sig"`
+		R         *frost.Element        `json:"r"`
+		Z         *frost.Scalar         `json:"z"`
+	}
+	in := record{
+		Keygen:    keygen,
+		Key:       keygen.GroupPublicKey,
+		Signer:    keygen.Participants[1],
+		Secret:    keygen.ParticipantPrivateKeys[1],
+		Nonce:     com,
+		Share:     &frost.SignatureShare{Identifier: com.Identifier, Share: sig.Z},
+		Signature: sig,
+		R:         sig.R,
+		Z:         sig.Z,
+	}
+	j, err := json.Marshal(in)
+	require.NoError(t, err)
+	var out record
+	require.NoError(t, json.Unmarshal(j, &out))
+	again, err := json.Marshal(out)
+	require.NoError(t, err)
+	require.JSONEq(t, string(j), string(again))
+	require.Equal(t, keygen.EncodeBinary(), out.Keygen.EncodeBinary())
+	require.Equal(t, sig.Bytes(), out.Signature.Bytes())
+
+	// Commitments keep their existing JSON encoding
+	legacy, err := com.EncodeJSON()
+	require.NoError(t, err)
+	current, err := json.Marshal(com)
+	require.NoError(t, err)
+	require.JSONEq(t, string(legacy), string(current))
+
+	_, err = json.Marshal(map[string]*frost.Element{"R": sig.R})
+	require.NoError(t, err)
+	require.Error(t, json.Unmarshal([]byte(`{"z":"not base64"}`), &out))
+	require.Error(t, json.Unmarshal([]byte(`{"key":"AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}`), &out))
+
+	// Zero identifiers
+	zero := frost.NewScalar()
+	signer, err := json.Marshal(&frost.Participant{Identifier: zero, PublicKeyShare: sig.R})
+	require.NoError(t, err)
+	require.Error(t, json.Unmarshal(signer, new(frost.Participant)))
+	secret, err := json.Marshal(&frost.SecretShare{Identifier: zero, Scalar: sig.Z})
+	require.NoError(t, err)
+	require.Error(t, json.Unmarshal(secret, new(frost.SecretShare)))
+	share, err := json.Marshal(&frost.SignatureShare{Identifier: zero, Share: sig.Z})
+	require.NoError(t, err)
+	require.Error(t, json.Unmarshal(share, new(frost.SignatureShare)))
+
+	// Commitments with a zero identifier, an identity element, or a
+	// non-canonical point
+	nonCanonical := append([]byte{0xed}, bytes.Repeat([]byte{0xff}, 30)...)
+	nonCanonical = append(nonCanonical, 0x7f)
+	for _, bad := range []func(v map[string]string){
+		func(v map[string]string) { v["i"] = base64.URLEncoding.EncodeToString(zero.Bytes()) },
+		func(v map[string]string) { v["h"] = "AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=" },
+		func(v map[string]string) { v["b"] = base64.URLEncoding.EncodeToString(nonCanonical) },
+	} {
+		j, err := json.Marshal(com)
+		require.NoError(t, err)
+		var v map[string]string
+		require.NoError(t, json.Unmarshal(j, &v))
+		bad(v)
+		j, err = json.Marshal(v)
+		require.NoError(t, err)
+		require.Error(t, json.Unmarshal(j, new(frost.Commitment)))
+	}
+
+	// Key generation output is checked like the binary encoding
+	for _, bad := range []func(v map[string]any){
+		func(v map[string]any) { v["participants"] = append(v["participants"].([]any), nil) },
+		func(v map[string]any) { v["secret_shares"] = []any{nil} },
+		func(v map[string]any) { v["vss_commitment"] = []any{} },
+		func(v map[string]any) { v["group_key"] = "AQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=" },
+		func(v map[string]any) { delete(v, "group_key") },
+	} {
+		j, err := json.Marshal(keygen)
+		require.NoError(t, err)
+		var v map[string]any
+		require.NoError(t, json.Unmarshal(j, &v))
+		bad(v)
+		j, err = json.Marshal(v)
+		require.NoError(t, err)
+		require.Error(t, json.Unmarshal(j, new(frost.KeygenOutput)))
+	}
+}
+
+func TestMarshalTextAndBinary(t *testing.T) {
+	keygen, sig := thresholdSign(t, []byte("text"))
+	values := []struct {
+		in, out marshaler
+	}{
+		{sig.Z, frost.NewScalar()},
+		{sig.R, frost.NewElement()},
+		{keygen.GroupPublicKey, new(frost.GroupKey)},
+		{keygen.Participants[0], new(frost.Participant)},
+		{keygen.ParticipantPrivateKeys[0], new(frost.SecretShare)},
+		{sig, new(frost.Signature)},
+		{keygen, new(frost.KeygenOutput)},
+	}
+	for _, v := range values {
+		b, err := v.in.MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, v.out.UnmarshalBinary(b))
+		again, err := v.out.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, b, again)
+
+		text, err := v.in.MarshalText()
+		require.NoError(t, err)
+		require.NoError(t, v.out.UnmarshalText(text))
+		againText, err := v.out.MarshalText()
+		require.NoError(t, err)
+		require.Equal(t, text, againText)
+
+		require.Error(t, v.out.UnmarshalBinary(b[:len(b)-1]))
+		require.Error(t, v.out.UnmarshalText(text[1:]))
+	}
+}
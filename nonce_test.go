@@ -0,0 +1,80 @@
+package frost_test
+
+import (
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonceSingleUse(t *testing.T) {
+	keygen, err := trusteddealer.NewTrustedDealer(csuite).Keygen(3, 2)
+	require.NoError(t, err)
+	msg := []byte("only once")
+
+	states := make([]*frost.State, 3)
+	commitments := make([]*frost.Commitment, 3)
+	for i := range states {
+		states[i] = frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[i])
+		commitments[i], err = states[i].Commit()
+		require.NoError(t, err)
+	}
+
+	_, err = states[0].Sign(commitments[:2])
+	require.NoError(t, err)
+
+	// Signing again with a different signing set would leak the secret share
+	_, err = states[0].Sign([]*frost.Commitment{commitments[0], commitments[2]})
+	require.ErrorIs(t, err, frost.ErrAlreadySigned)
+	_, err = states[0].Commit()
+	require.ErrorIs(t, err, frost.ErrAlreadySigned)
+}
+
+func TestNonceZeroizedAndNotShared(t *testing.T) {
+	keygen, err := trusteddealer.NewTrustedDealer(csuite).Keygen(3, 2)
+	require.NoError(t, err)
+	msg := []byte("shared nonce")
+
+	hiding, err := NewScalar64(1234)
+	require.NoError(t, err)
+	binding, err := NewScalar64(5678)
+	require.NoError(t, err)
+	nonce := &frost.Nonce{Hiding: hiding, Binding: binding}
+
+	a := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[0])
+	b := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, []byte("other"), keygen.ParticipantPrivateKeys[0])
+	require.NoError(t, a.SetNonce(nonce))
+	require.ErrorIs(t, b.SetNonce(nonce), frost.ErrNonceReused)
+	require.Error(t, b.SetNonce(&frost.Nonce{Hiding: hiding}))
+
+	other := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[1])
+	otherCommitment, err := other.Commit()
+	require.NoError(t, err)
+
+	// A failed Sign still burns the nonce
+	_, err = a.Sign([]*frost.Commitment{otherCommitment})
+	require.Error(t, err)
+	zero := frost.NewScalar()
+	require.True(t, nonce.Hiding.Equal(zero))
+	require.True(t, nonce.Binding.Equal(zero))
+
+	_, err = a.Sign([]*frost.Commitment{a.MyCommitment, otherCommitment})
+	require.ErrorIs(t, err, frost.ErrAlreadySigned)
+
+	// The zeroized scalars cannot come back in a fresh Nonce
+	require.Error(t, b.SetNonce(&frost.Nonce{Hiding: hiding, Binding: binding}))
+}
+
+func TestSignWithoutNonce(t *testing.T) {
+	keygen, err := trusteddealer.NewTrustedDealer(csuite).Keygen(3, 2)
+	require.NoError(t, err)
+	msg := []byte("no nonce")
+
+	other := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[1])
+	otherCommitment, err := other.Commit()
+	require.NoError(t, err)
+	state := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[0])
+	_, err = state.Sign([]*frost.Commitment{otherCommitment})
+	require.ErrorIs(t, err, frost.ErrNoNonce)
+}
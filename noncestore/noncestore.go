@@ -0,0 +1,304 @@
+// Package noncestore provides storage for preprocessed FROST nonces, so that a
+// signer can publish commitments ahead of time and later sign in one round.
+// The same stores also act as replay guards for restoring exported states.
+//
+// Every store hands out each nonce at most once. Reusing a nonce with two
+// different signing sets leaks the signer's secret share.
+package noncestore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/soatok/frost/internal"
+)
+
+// Suffix for files whose nonce has been taken
+const tombstoneSuffix = ".used"
+
+// The hiding and binding commitments uniquely identify a nonce.
+func commitmentKey(c *internal.Commitment) string {
+	return hex.EncodeToString(append(c.Hiding.Bytes(), c.Binding.Bytes()...))
+}
+
+// MemoryStore keeps nonces in memory. It does not survive a restart, but
+// neither do its nonces, so it is safe to use in a single process.
+type MemoryStore struct {
+	mu     sync.Mutex
+	nonces map[string]*internal.Nonce
+	used   map[string]bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		nonces: make(map[string]*internal.Nonce),
+		used:   make(map[string]bool),
+	}
+}
+
+// Implement internal.NonceStore
+func (m *MemoryStore) Put(commitment *internal.Commitment, nonce *internal.Nonce) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := commitmentKey(commitment)
+	if _, ok := m.nonces[key]; ok || m.used[key] {
+		return internal.ErrNonceReused
+	}
+	m.nonces[key] = nonce
+	return nil
+}
+
+// Implement internal.NonceStore
+func (m *MemoryStore) Take(commitment *internal.Commitment) (*internal.Nonce, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := commitmentKey(commitment)
+	if m.used[key] {
+		return nil, internal.ErrNonceReused
+	}
+	nonce, ok := m.nonces[key]
+	if !ok {
+		return nil, internal.ErrNonceNotFound
+	}
+	delete(m.nonces, key)
+	m.used[key] = true
+	return nonce, nil
+}
+
+// Implement internal.ReplayGuard
+func (m *MemoryStore) Consume(commitment *internal.Commitment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := commitmentKey(commitment)
+	if m.used[key] {
+		return internal.ErrNonceReused
+	}
+	delete(m.nonces, key)
+	m.used[key] = true
+	return nil
+}
+
+// Len returns how many unused nonces remain.
+func (m *MemoryStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.nonces)
+}
+
+// FileStore keeps each nonce in its own file inside a directory.
+//
+// Take claims a nonce by atomically renaming its file to a tombstone before
+// reading it, and the tombstone is kept (emptied) afterwards. A crash at any
+// point can therefore lose a nonce, but never hand it out twice.
+//
+// The directory is created with mode 0700 and the files with mode 0600. A
+// store from NewFileStore writes the nonces in plaintext, and is meant for
+// tests; use NewEncryptedFileStore for anything else.
+type FileStore struct {
+	dir  string
+	aead cipher.AEAD
+}
+
+// Size of the key for NewEncryptedFileStore
+const KeySize = 32
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// NewEncryptedFileStore creates a FileStore that encrypts each nonce under a
+// 32-byte key with AES-256-GCM, bound to its commitment.
+func NewEncryptedFileStore(dir string, key []byte) (*FileStore, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("nonce store key must be %d bytes", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	f, err := NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	f.aead = aead
+	return f, nil
+}
+
+// Encrypt a nonce, if the store has a key.
+func (f *FileStore) seal(key string, buf []byte) ([]byte, error) {
+	if f.aead == nil {
+		return append([]byte{}, buf...), nil
+	}
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return f.aead.Seal(nonce, nonce, buf, []byte(key)), nil
+}
+
+// Decrypt a nonce, if the store has a key.
+func (f *FileStore) open(key string, buf []byte) ([]byte, error) {
+	if f.aead == nil {
+		return append([]byte{}, buf...), nil
+	}
+	if len(buf) < f.aead.NonceSize() {
+		return nil, errors.New("corrupt nonce file")
+	}
+	n := f.aead.NonceSize()
+	pt, err := f.aead.Open(nil, buf[:n], buf[n:], []byte(key))
+	if err != nil {
+		return nil, errors.New("corrupt nonce file")
+	}
+	return pt, nil
+}
+
+// Implement internal.NonceStore
+func (f *FileStore) Put(commitment *internal.Commitment, nonce *internal.Nonce) error {
+	key := commitmentKey(commitment)
+	path := filepath.Join(f.dir, key)
+	if _, err := os.Stat(path + tombstoneSuffix); err == nil {
+		return internal.ErrNonceReused
+	}
+
+	// Write to a temporary file first, so a partially written nonce is never
+	// visible under its final name.
+	tmp, err := os.CreateTemp(f.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	buf := append(nonce.Hiding.Bytes(), nonce.Binding.Bytes()...)
+	defer clear(buf)
+	sealed, err := f.seal(key, buf)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	defer clear(sealed)
+	if _, err := tmp.Write(sealed); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Link rather than rename, so an existing nonce is never overwritten.
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return internal.ErrNonceReused
+		}
+		return err
+	}
+	return f.syncDir()
+}
+
+// Implement internal.NonceStore
+func (f *FileStore) Take(commitment *internal.Commitment) (*internal.Nonce, error) {
+	key := commitmentKey(commitment)
+	path := filepath.Join(f.dir, key)
+	tombstone := path + tombstoneSuffix
+
+	if err := os.Rename(path, tombstone); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			if _, statErr := os.Stat(tombstone); statErr == nil {
+				return nil, internal.ErrNonceReused
+			}
+			return nil, internal.ErrNonceNotFound
+		}
+		return nil, err
+	}
+	if err := f.syncDir(); err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(tombstone)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(raw)
+	if err := os.WriteFile(tombstone, nil, 0o600); err != nil {
+		return nil, err
+	}
+	buf, err := f.open(key, raw)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(buf)
+	if len(buf) != 64 {
+		return nil, errors.New("corrupt nonce file")
+	}
+	hiding, err := internal.NewScalar().SetBytes(buf[:32])
+	if err != nil {
+		return nil, err
+	}
+	binding, err := internal.NewScalar().SetBytes(buf[32:])
+	if err != nil {
+		return nil, err
+	}
+	return &internal.Nonce{Hiding: hiding, Binding: binding}, nil
+}
+
+// Implement internal.ReplayGuard by leaving a tombstone behind.
+func (f *FileStore) Consume(commitment *internal.Commitment) error {
+	path := filepath.Join(f.dir, commitmentKey(commitment))
+	fh, err := os.OpenFile(path+tombstoneSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return internal.ErrNonceReused
+		}
+		return err
+	}
+	if err := fh.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return f.syncDir()
+}
+
+// Len returns how many unused nonces remain.
+func (f *FileStore) Len() (int, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range entries {
+		name := e.Name()
+		if len(name) == 128 {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Make renames and links durable.
+func (f *FileStore) syncDir() error {
+	d, err := os.Open(f.dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,92 @@
+package frost_test
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/stretchr/testify/require"
+)
+
+// The public key of RFC 8032's first test vector, which is also the key of
+// RFC 8037's example JWK. The PEM was produced with openssl.
+const (
+	exportKey  = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+	exportSSH  = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINdamAGCsQq31Uv+08lkBzoO4XLz2qYjJa8CGmj3B1Ea group@example\n"
+	exportJWK  = `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	exportPKIX = "302a300506032b6570032100" + exportKey
+	exportPEM  = "-----BEGIN PUBLIC KEY-----\nMCowBQYDK2VwAyEA11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=\n-----END PUBLIC KEY-----\n"
+)
+
+func TestGroupKeyExportVectors(t *testing.T) {
+	raw, err := hex.DecodeString(exportKey)
+	require.NoError(t, err)
+	gk, err := frost.GroupKeyFromBytes(raw)
+	require.NoError(t, err)
+
+	require.Equal(t, ed25519.PublicKey(raw), gk.Ed25519())
+	require.Equal(t, exportSSH, string(gk.AuthorizedKey("group@example")))
+	jwk, err := gk.JWK()
+	require.NoError(t, err)
+	require.Equal(t, exportJWK, string(jwk))
+	der, err := gk.PKIX()
+	require.NoError(t, err)
+	require.Equal(t, exportPKIX, hex.EncodeToString(der))
+	pemBytes, err := gk.PEM()
+	require.NoError(t, err)
+	require.Equal(t, exportPEM, string(pemBytes))
+
+	parsed, comment, err := frost.GroupKeyFromAuthorizedKey([]byte(`restrict,command="true" ` + exportSSH))
+	require.NoError(t, err)
+	require.Equal(t, "group@example", comment)
+	require.Equal(t, raw, parsed.Bytes())
+	parsed, err = frost.GroupKeyFromJWK([]byte(exportJWK))
+	require.NoError(t, err)
+	require.Equal(t, raw, parsed.Bytes())
+	parsed, err = frost.GroupKeyFromPKIX(der)
+	require.NoError(t, err)
+	require.Equal(t, raw, parsed.Bytes())
+	parsed, err = frost.GroupKeyFromPEM([]byte(exportPEM))
+	require.NoError(t, err)
+	require.Equal(t, raw, parsed.Bytes())
+	parsed, err = frost.GroupKeyFromEd25519(ed25519.PublicKey(raw))
+	require.NoError(t, err)
+	require.Equal(t, raw, parsed.Bytes())
+}
+
+// A threshold signature verifies under every exported form of the key.
+func TestGroupKeyExportVerifies(t *testing.T) {
+	msg := []byte("export")
+	keygen, sig := thresholdSign(t, msg)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Ed25519(), msg, sig.Bytes()))
+
+	parsed, _, err := frost.GroupKeyFromAuthorizedKey(keygen.GroupPublicKey.AuthorizedKey(""))
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(parsed.Ed25519(), msg, sig.Bytes()))
+}
+
+func TestGroupKeyImportRejects(t *testing.T) {
+	identity := "01" + "00000000000000000000000000000000000000000000000000000000000000"
+	raw, err := hex.DecodeString(identity)
+	require.NoError(t, err)
+	_, err = frost.GroupKeyFromEd25519(raw)
+	require.Error(t, err)
+	_, err = frost.GroupKeyFromEd25519(raw[:31])
+	require.Error(t, err)
+
+	// A private JWK, and one for another curve
+	_, err = frost.GroupKeyFromJWK([]byte(`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`))
+	require.Error(t, err)
+	_, err = frost.GroupKeyFromJWK([]byte(`{"kty":"OKP","crv":"X25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+	require.Error(t, err)
+
+	// Other SSH key types, and a blob that disagrees with the line's key type
+	_, _, err = frost.GroupKeyFromAuthorizedKey([]byte("ssh-rsa AAAAB3NzaC1yc2EAAAADAQAB user"))
+	require.Error(t, err)
+	_, _, err = frost.GroupKeyFromAuthorizedKey([]byte("ssh-ed25519 AAAAB3NzaC1yc2EAAAADAQAB user"))
+	require.Error(t, err)
+
+	_, err = frost.GroupKeyFromPEM([]byte("-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"))
+	require.Error(t, err)
+}
@@ -0,0 +1,270 @@
+// Package roast implements ROAST (Robust Asynchronous Schnorr Threshold
+// signatures), a wrapper around the FROST signing rounds that guarantees a
+// signature as long as at least t honest signers eventually respond.
+//
+// The coordinator keeps starting new FROST sessions from whichever t signers
+// have most recently responded, and permanently excludes signers whose shares
+// fail verification. Every response to a session carries the signer's
+// commitment for their next session, so an honest signer is never left
+// waiting on a stalled one.
+//
+// https://eprint.iacr.org/2022/550
+package roast
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/internal"
+)
+
+// ErrTooManyMalicious is returned once fewer than t signers remain that have
+// not misbehaved, so no signature can ever be produced.
+var ErrTooManyMalicious = errors.New("too many malicious signers to reach the threshold")
+
+// Update tells the caller what to do after the coordinator processed a message.
+type Update struct {
+	// Sessions are new signing packages. Each must be sent to every signer
+	// that has a commitment in it.
+	Sessions []*frost.SigningPackage
+
+	// Signature is set once any session has completed.
+	Signature *frost.Signature
+}
+
+// Coordinator is the ROAST coordinator. It does no networking itself: feed it
+// the messages you receive, and send out the signing packages it returns.
+type Coordinator struct {
+	c            internal.Ciphersuite
+	participants []*frost.Participant
+	groupKey     *frost.GroupKey
+	threshold    int
+	msg          []byte
+
+	// Latest unused commitment of each responsive signer, in arrival order
+	responsive []*frost.Commitment
+	// Signers whose shares failed verification
+	malicious []*frost.Scalar
+	// Session ID each signer is currently working on
+	assigned map[string]uint64
+	sessions map[uint64]*frost.Coordinator
+	nextID   uint64
+	sig      *frost.Signature
+}
+
+func NewCoordinator(c internal.Ciphersuite, participants []*frost.Participant, groupKey *frost.GroupKey, threshold int, msg []byte) (*Coordinator, error) {
+	if err := internal.CheckThreshold(threshold, len(participants)); err != nil {
+		return nil, err
+	}
+	return &Coordinator{
+		c:            c,
+		participants: participants,
+		groupKey:     groupKey,
+		threshold:    threshold,
+		msg:          msg,
+		assigned:     make(map[string]uint64),
+		sessions:     make(map[uint64]*frost.Coordinator),
+	}, nil
+}
+
+// AddCommitment records a signer's initial commitment.
+func (co *Coordinator) AddCommitment(com *frost.Commitment) (*Update, error) {
+	if co.sig != nil {
+		return &Update{Signature: co.sig}, nil
+	}
+	if err := internal.ValidateCommitment(com); err != nil {
+		return nil, err
+	}
+	if err := co.checkSigner(com.Identifier); err != nil {
+		return nil, err
+	}
+	if _, busy := co.assigned[key(com.Identifier)]; busy {
+		return nil, fmt.Errorf("signer already has a session; its next commitment comes with its share")
+	}
+	for _, r := range co.responsive {
+		if r.Identifier.Equal(com.Identifier) {
+			return nil, fmt.Errorf("duplicate commitment from signer")
+		}
+	}
+	co.responsive = append(co.responsive, com)
+	return co.maybeStartSession()
+}
+
+// AddResponse processes a signer's share for a session, along with their
+// commitment for the next session.
+//
+// A share that fails verification marks the signer as malicious: they are
+// never included in another session, and no error is returned unless too
+// many signers have been excluded to ever finish.
+func (co *Coordinator) AddResponse(sessionID []byte, share *frost.SignatureShare, next *frost.Commitment) (*Update, error) {
+	if co.sig != nil {
+		return &Update{Signature: co.sig}, nil
+	}
+	if share == nil || share.Identifier == nil || share.Share == nil {
+		return nil, fmt.Errorf("incomplete response")
+	}
+	if err := internal.ValidateCommitment(next); err != nil {
+		return nil, err
+	}
+	if !share.Identifier.Equal(next.Identifier) {
+		return nil, fmt.Errorf("share and next commitment come from different signers")
+	}
+	if err := co.checkSigner(share.Identifier); err != nil {
+		return nil, err
+	}
+	if len(sessionID) != 8 {
+		return nil, fmt.Errorf("invalid session ID")
+	}
+	id := binary.BigEndian.Uint64(sessionID)
+	if assigned, ok := co.assigned[key(share.Identifier)]; !ok || assigned != id {
+		return nil, fmt.Errorf("signer was not assigned to this session")
+	}
+	session := co.sessions[id]
+
+	err := session.AddSignatureShare(share)
+	if errors.Is(err, frost.ErrInvalidShare) {
+		co.malicious = append(co.malicious, share.Identifier)
+		delete(co.assigned, key(share.Identifier))
+		if len(co.participants)-len(co.malicious) < co.threshold {
+			return nil, ErrTooManyMalicious
+		}
+		return &Update{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	delete(co.assigned, key(share.Identifier))
+
+	if len(session.Missing()) == 0 {
+		sig, err := session.Aggregate()
+		if err != nil {
+			return nil, err
+		}
+		co.sig = sig
+		return &Update{Signature: sig}, nil
+	}
+
+	co.responsive = append(co.responsive, next)
+	return co.maybeStartSession()
+}
+
+// Malicious returns the identifiers of signers excluded for bad shares.
+func (co *Coordinator) Malicious() []*frost.Scalar {
+	return append([]*frost.Scalar{}, co.malicious...)
+}
+
+// Signature returns the final signature, or nil if no session has finished.
+func (co *Coordinator) Signature() *frost.Signature {
+	return co.sig
+}
+
+// Start a session once t responsive signers are available.
+func (co *Coordinator) maybeStartSession() (*Update, error) {
+	update := &Update{}
+	if len(co.responsive) < co.threshold {
+		return update, nil
+	}
+	chosen := co.responsive[:co.threshold]
+	id := co.nextID
+
+	// Build the whole session before touching any state, so a failure
+	// leaves the signers free for the next one
+	session, err := frost.NewCoordinator(co.c, co.participants, co.groupKey, co.threshold, co.msg)
+	if err != nil {
+		return nil, err
+	}
+	session.SessionID = binary.BigEndian.AppendUint64(nil, id)
+	for _, com := range chosen {
+		if err := session.AddCommitment(com); err != nil {
+			return nil, err
+		}
+	}
+	pkg, err := session.SigningPackage()
+	if err != nil {
+		return nil, err
+	}
+
+	co.responsive = co.responsive[co.threshold:]
+	co.nextID++
+	for _, com := range chosen {
+		co.assigned[key(com.Identifier)] = id
+	}
+	co.sessions[id] = session
+	update.Sessions = append(update.Sessions, pkg)
+	return update, nil
+}
+
+func (co *Coordinator) checkSigner(id *frost.Scalar) error {
+	for _, m := range co.malicious {
+		if m.Equal(id) {
+			return fmt.Errorf("signer was excluded as malicious")
+		}
+	}
+	for _, p := range co.participants {
+		if p.Identifier.Equal(id) {
+			return nil
+		}
+	}
+	return fmt.Errorf("participant not found")
+}
+
+// Signer is the signer's side of ROAST. It always holds exactly one unused
+// commitment, and replaces it every time it signs.
+type Signer struct {
+	// Policy, if set, is given to every State the signer creates
+	Policy frost.Policy
+
+	c            internal.Ciphersuite
+	participants []*frost.Participant
+	groupKey     *frost.GroupKey
+	msg          []byte
+	secretShare  *frost.SecretShare
+	state        *frost.State
+}
+
+func NewSigner(c internal.Ciphersuite, participants []*frost.Participant, groupKey *frost.GroupKey, msg []byte, mySecretShare *frost.SecretShare) *Signer {
+	return &Signer{
+		c:            c,
+		participants: participants,
+		groupKey:     groupKey,
+		msg:          msg,
+		secretShare:  mySecretShare,
+	}
+}
+
+// Commit produces the signer's initial commitment.
+func (s *Signer) Commit() (*frost.Commitment, error) {
+	if s.state != nil {
+		return nil, fmt.Errorf("signer already committed")
+	}
+	return s.fresh()
+}
+
+// Sign responds to a signing package with a share, and the commitment to use
+// for the next session.
+func (s *Signer) Sign(pkg *frost.SigningPackage) (*frost.SignatureShare, *frost.Commitment, error) {
+	if s.state == nil {
+		return nil, nil, fmt.Errorf("signer has not committed")
+	}
+	share, err := s.state.SignPackage(pkg)
+	if err != nil {
+		return nil, nil, err
+	}
+	next, err := s.fresh()
+	if err != nil {
+		return nil, nil, err
+	}
+	return share, next, nil
+}
+
+func (s *Signer) fresh() (*frost.Commitment, error) {
+	s.state = frost.NewState(s.c, s.participants, s.groupKey, s.msg, s.secretShare)
+	s.state.Policy = s.Policy
+	return s.state.Commit()
+}
+
+func key(id *frost.Scalar) string {
+	return string(id.Bytes())
+}
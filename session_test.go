@@ -0,0 +1,88 @@
+package frost_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	keygen, err := trusteddealer.NewTrustedDealer(csuite).Keygen(3, 2)
+	require.NoError(t, err)
+	msg := []byte("in order")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	signers := make([]*frost.Session, 2)
+	commitments := make([]*frost.Commitment, 2)
+	for i := range signers {
+		signers[i] = frost.NewSession(frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[i]))
+		require.Equal(t, frost.PhaseNew, signers[i].Phase())
+
+		_, err = signers[i].Sign(ctx, nil)
+		require.ErrorIs(t, err, frost.ErrOutOfOrder)
+
+		commitments[i], err = signers[i].Commit(ctx)
+		require.NoError(t, err)
+		require.Equal(t, frost.PhaseCommitted, signers[i].Phase())
+
+		_, err = signers[i].Commit(ctx)
+		require.ErrorIs(t, err, frost.ErrOutOfOrder)
+	}
+
+	coordinator := frost.NewSession(frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, nil))
+	_, err = coordinator.Commit(ctx)
+	require.ErrorIs(t, err, frost.ErrOutOfOrder)
+	_, err = coordinator.Aggregate(ctx, nil)
+	require.ErrorIs(t, err, frost.ErrOutOfOrder)
+	_, err = coordinator.Sign(ctx, commitments)
+	require.NoError(t, err)
+	require.Equal(t, frost.PhaseSigned, coordinator.Phase())
+
+	shares := make([]*frost.SignatureShare, 2)
+	for i := range signers {
+		shares[i], err = signers[i].Sign(ctx, commitments)
+		require.NoError(t, err)
+		require.Equal(t, frost.PhaseSigned, signers[i].Phase())
+
+		ok, err := coordinator.VerifySignatureShare(ctx, shares[i])
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	sig, err := coordinator.Aggregate(ctx, shares)
+	require.NoError(t, err)
+	require.Equal(t, frost.PhaseAggregated, coordinator.Phase())
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), msg, sig.Bytes()))
+
+	_, err = coordinator.Aggregate(ctx, shares)
+	require.ErrorIs(t, err, frost.ErrOutOfOrder)
+}
+
+func TestSessionExpiry(t *testing.T) {
+	keygen, err := trusteddealer.NewTrustedDealer(csuite).Keygen(3, 2)
+	require.NoError(t, err)
+	msg := []byte("too slow")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	state := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[0])
+	session := frost.NewSession(state)
+	commitment, err := session.Commit(ctx)
+	require.NoError(t, err)
+
+	cancel()
+	_, err = session.Sign(ctx, []*frost.Commitment{commitment})
+	require.ErrorIs(t, err, frost.ErrSessionExpired)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, frost.PhaseFailed, session.Phase())
+
+	// The nonce was discarded along with the session
+	_, err = state.Sign([]*frost.Commitment{commitment})
+	require.ErrorIs(t, err, frost.ErrNonceReused)
+}
@@ -0,0 +1,295 @@
+// Package sharefile stores a participant's secret share at rest, encrypted
+// under a passphrase and armored as PEM:
+//
+//	-----BEGIN FROST SECRET SHARE-----
+//	Suite: FROST-ED25519-SHA512-v1
+//	Group-Key: SHA256:<base64 fingerprint of the group key>
+//	Identifier: <hex identifier>
+//	KDF: argon2id,t=3,m=65536,p=4
+//	Salt: <base64 salt>
+//
+//	<base64 of nonce || AES-256-GCM ciphertext || SHA-256 checksum>
+//	-----END FROST SECRET SHARE-----
+//
+// The key is derived from the passphrase with Argon2id. Every header is
+// authenticated as additional data, so they cannot be edited without the
+// passphrase. The unkeyed checksum covers the headers and the ciphertext; it
+// lets Decode tell a damaged file (ErrCorrupted) from a wrong passphrase
+// (ErrWrongPassphrase).
+package sharefile
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/internal"
+	"golang.org/x/crypto/argon2"
+)
+
+// PEMType is the PEM block type of a secret share file.
+const PEMType = "FROST SECRET SHARE"
+
+var (
+	// ErrWrongPassphrase is returned when an intact file fails to decrypt.
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+
+	// ErrCorrupted is returned when a file is damaged or malformed.
+	ErrCorrupted = errors.New("secret share file is corrupted")
+)
+
+// Params are the Argon2id parameters. Memory is in KiB.
+type Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// DefaultParams follows the second recommendation of RFC 9106: 64 MiB of
+// memory and three passes.
+var DefaultParams = Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// Upper bounds on parameters read from a file, so that a hostile file cannot
+// make Decode use more than 1 GiB of memory or ten passes over it.
+const (
+	maxTime   = 10
+	maxMemory = 1024 * 1024
+)
+
+const (
+	saltSize     = 16
+	checksumSize = sha256.Size
+)
+
+// Header is the unencrypted metadata of a secret share file.
+type Header struct {
+	Suite       string
+	GroupKey    string
+	Identifier  *frost.Scalar
+	Params      Params
+	salt        []byte
+	headerBytes []byte
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a group key, in the form
+// used by the Group-Key header.
+func Fingerprint(gk *frost.GroupKey) string {
+	sum := sha256.Sum256(gk.Bytes())
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// Matches reports whether the file belongs to the given group key.
+func (h *Header) Matches(gk *frost.GroupKey) bool {
+	return subtle.ConstantTimeCompare([]byte(h.GroupKey), []byte(Fingerprint(gk))) == 1
+}
+
+// Encode encrypts a secret share under a passphrase with DefaultParams.
+func Encode(share *frost.SecretShare, groupKey *frost.GroupKey, passphrase []byte) ([]byte, error) {
+	return EncodeWithParams(share, groupKey, passphrase, DefaultParams)
+}
+
+// EncodeWithParams encrypts a secret share with the given Argon2id
+// parameters.
+func EncodeWithParams(share *frost.SecretShare, groupKey *frost.GroupKey, passphrase []byte, params Params) ([]byte, error) {
+	if params.Time == 0 || params.Time > maxTime || params.Memory < 8*uint32(params.Threads) || params.Memory > maxMemory || params.Threads == 0 {
+		return nil, fmt.Errorf("invalid Argon2id parameters")
+	}
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	h := &Header{
+		Suite:      internal.ContextString,
+		GroupKey:   Fingerprint(groupKey),
+		Identifier: share.Identifier,
+		Params:     params,
+		salt:       salt,
+	}
+	headers := h.pemHeaders()
+
+	aead, err := newAEAD(passphrase, h)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	pt := share.EncodeBinary()
+	defer clear(pt)
+	ad := additionalData(headers)
+	body := aead.Seal(nonce, nonce, pt, ad)
+	sum := checksum(ad, body)
+	body = append(body, sum[:]...)
+
+	return pem.EncodeToMemory(&pem.Block{Type: PEMType, Headers: headers, Bytes: body}), nil
+}
+
+// Write encrypts a secret share to w with DefaultParams.
+func Write(w io.Writer, share *frost.SecretShare, groupKey *frost.GroupKey, passphrase []byte) error {
+	out, err := Encode(share, groupKey, passphrase)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// ReadHeader parses the headers of a file without decrypting it.
+func ReadHeader(data []byte) (*Header, error) {
+	_, h, err := parseFile(data)
+	return h, err
+}
+
+// Parse the PEM block of a file and its headers.
+func parseFile(data []byte) (*pem.Block, *Header, error) {
+	block, rest := pem.Decode(data)
+	if block == nil || block.Type != PEMType || len(bytes.TrimSpace(rest)) != 0 {
+		return nil, nil, fmt.Errorf("%w: no %s PEM block", ErrCorrupted, PEMType)
+	}
+	h, err := parseHeaders(block.Headers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	return block, h, nil
+}
+
+// Decode decrypts a secret share file. It returns ErrCorrupted if the file is
+// damaged, and ErrWrongPassphrase if it is intact but the passphrase is wrong.
+func Decode(data []byte, passphrase []byte) (*frost.SecretShare, *Header, error) {
+	block, h, err := parseFile(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := block.Bytes
+	if len(body) < 12+16+checksumSize {
+		return nil, nil, fmt.Errorf("%w: body too short", ErrCorrupted)
+	}
+	sealed, sum := body[:len(body)-checksumSize], body[len(body)-checksumSize:]
+	ad := additionalData(block.Headers)
+	want := checksum(ad, sealed)
+	if subtle.ConstantTimeCompare(sum, want[:]) != 1 {
+		return nil, nil, fmt.Errorf("%w: checksum mismatch", ErrCorrupted)
+	}
+
+	aead, err := newAEAD(passphrase, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, ct := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	pt, err := aead.Open(nil, nonce, ct, ad)
+	if err != nil {
+		return nil, nil, ErrWrongPassphrase
+	}
+	defer clear(pt)
+	share, err := frost.SecretShareFromBinary(pt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	if !share.Identifier.Equal(h.Identifier) {
+		return nil, nil, fmt.Errorf("%w: identifier does not match header", ErrCorrupted)
+	}
+	return share, h, nil
+}
+
+// Read decrypts a secret share file from r.
+func Read(r io.Reader, passphrase []byte) (*frost.SecretShare, *Header, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Decode(data, passphrase)
+}
+
+func (h *Header) pemHeaders() map[string]string {
+	return map[string]string{
+		"Suite":      h.Suite,
+		"Group-Key":  h.GroupKey,
+		"Identifier": hex.EncodeToString(h.Identifier.Bytes()),
+		"KDF":        fmt.Sprintf("argon2id,t=%d,m=%d,p=%d", h.Params.Time, h.Params.Memory, h.Params.Threads),
+		"Salt":       base64.StdEncoding.EncodeToString(h.salt),
+	}
+}
+
+// The headers that must be present, in the order they are authenticated.
+var headerNames = []string{"Suite", "Group-Key", "Identifier", "KDF", "Salt"}
+
+func parseHeaders(headers map[string]string) (*Header, error) {
+	if len(headers) != len(headerNames) {
+		return nil, fmt.Errorf("unexpected PEM headers")
+	}
+	for _, name := range headerNames {
+		if _, ok := headers[name]; !ok {
+			return nil, fmt.Errorf("missing %s header", name)
+		}
+	}
+	h := &Header{Suite: headers["Suite"], GroupKey: headers["Group-Key"]}
+	if h.Suite != internal.ContextString {
+		return nil, fmt.Errorf("unsupported suite %q", h.Suite)
+	}
+	if !strings.HasPrefix(h.GroupKey, "SHA256:") {
+		return nil, fmt.Errorf("malformed Group-Key header")
+	}
+	id, err := hex.DecodeString(headers["Identifier"])
+	if err != nil {
+		return nil, fmt.Errorf("malformed Identifier header")
+	}
+	if h.Identifier, err = frost.ScalarFromBytes(id); err != nil {
+		return nil, fmt.Errorf("malformed Identifier header")
+	}
+	var p Params
+	if _, err := fmt.Sscanf(headers["KDF"], "argon2id,t=%d,m=%d,p=%d", &p.Time, &p.Memory, &p.Threads); err != nil {
+		return nil, fmt.Errorf("malformed KDF header")
+	}
+	if p.Time == 0 || p.Time > maxTime || p.Memory < 8*uint32(p.Threads) || p.Memory > maxMemory || p.Threads == 0 {
+		return nil, fmt.Errorf("KDF parameters out of range")
+	}
+	h.Params = p
+	if h.salt, err = base64.StdEncoding.DecodeString(headers["Salt"]); err != nil || len(h.salt) != saltSize {
+		return nil, fmt.Errorf("malformed Salt header")
+	}
+	return h, nil
+}
+
+// Serialize the headers in a fixed order, each as a length-prefixed name and
+// value.
+func additionalData(headers map[string]string) []byte {
+	ad := []byte(internal.ContextString + "-sharefile")
+	for _, name := range headerNames {
+		for _, s := range []string{name, headers[name]} {
+			ad = append(ad, byte(len(s)>>8), byte(len(s)))
+			ad = append(ad, s...)
+		}
+	}
+	return ad
+}
+
+func checksum(ad, sealed []byte) [checksumSize]byte {
+	h := sha256.New()
+	h.Write(ad)
+	h.Write(sealed)
+	var sum [checksumSize]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func newAEAD(passphrase []byte, h *Header) (cipher.AEAD, error) {
+	key := argon2.IDKey(passphrase, h.salt, h.Params.Time, h.Params.Memory, h.Params.Threads, 32)
+	defer clear(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
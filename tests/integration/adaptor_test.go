@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/internal"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptorSignature(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	message := []byte("swap 1 BTC for 20 XMR")
+
+	// The counterparty's secret, which the signers never see
+	secret, err := internal.RandomScalar()
+	require.NoError(t, err)
+	adaptor := frost.NewElement().Mul(secret, nil)
+
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+	require.NoError(t, err)
+	coordinator.AdaptorPoint = adaptor
+	states := make([]*frost.State, 2)
+	for i := range states {
+		states[i] = frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitment, err := states[i].Commit()
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddCommitment(commitment))
+	}
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+	received, err := frost.SigningPackageFromBytes(pkg.Bytes())
+	require.NoError(t, err)
+	require.True(t, received.AdaptorPoint.Equal(adaptor))
+
+	for _, state := range states {
+		share, err := state.SignPackage(received)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddSignatureShare(share))
+	}
+	_, err = coordinator.Aggregate()
+	require.Error(t, err, "adaptor signatures cannot be aggregated directly")
+
+	pre, err := coordinator.AggregatePreSignature()
+	require.NoError(t, err)
+	ok, err := frost.VerifyPreSignature(c, keygen.GroupPublicKey, message, pre)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = frost.VerifyPreSignature(c, keygen.GroupPublicKey, []byte("swap 2 BTC"), pre)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// The pre-signature on its own is not a valid signature
+	require.False(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, (&frost.Signature{R: pre.R, Z: pre.Z}).Bytes()))
+
+	_, err = pre.Complete(frost.NewScalar().Add(secret, secret))
+	require.Error(t, err)
+	sig, err := pre.Complete(secret)
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+
+	// Seeing the completed signature reveals the secret
+	extracted, err := pre.Extract(sig)
+	require.NoError(t, err)
+	require.True(t, extracted.Equal(secret))
+}
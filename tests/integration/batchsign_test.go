@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchSigning(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(4, 3)
+	require.NoError(t, err)
+
+	messages := make([][]byte, 12)
+	for i := range messages {
+		messages[i] = fmt.Appendf(nil, "release-artifact-%d.tar.gz", i)
+	}
+
+	coordinator, err := frost.NewBatchCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 3, messages)
+	require.NoError(t, err)
+	signers := make([]*frost.BatchState, 3)
+
+	// Round trip 1: N commitments per signer
+	for i := range signers {
+		signers[i] = frost.NewBatchState(c, keygen.Participants, keygen.GroupPublicKey, messages, keygen.ParticipantPrivateKeys[i])
+		commitments, err := signers[i].Commit()
+		require.NoError(t, err)
+		require.Len(t, commitments, len(messages))
+		require.Error(t, coordinator.AddCommitments(commitments[1:]))
+		require.NoError(t, coordinator.AddCommitments(commitments))
+	}
+	pkgs, err := coordinator.SigningPackages()
+	require.NoError(t, err)
+	require.Len(t, pkgs, len(messages))
+
+	// A single bad package means no shares are released
+	swapped := append([]*frost.SigningPackage{}, pkgs...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	_, err = signers[0].SignPackages(swapped)
+	require.Error(t, err)
+
+	// Round trip 2: N shares per signer
+	for _, signer := range signers {
+		shares, err := signer.SignPackages(pkgs)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddSignatureShares(shares))
+	}
+
+	sigs, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	require.Len(t, sigs, len(messages))
+	for i, sig := range sigs {
+		require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), messages[i], sig.Bytes()))
+		if i > 0 {
+			require.NotEqual(t, sigs[i-1].R.Bytes(), sig.R.Bytes())
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlindSigning(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	token := []byte("anonymous token 7f3a")
+
+	// Signers and coordinator never see the message
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, nil)
+	require.NoError(t, err)
+	signers := make([]*frost.BlindSigner, 2)
+	states := make([]*frost.State, 2)
+	for i := range states {
+		signers[i], err = frost.NewBlindSigner(c, keygen.Participants, keygen.GroupPublicKey, keygen.ParticipantPrivateKeys[i], 1)
+		require.NoError(t, err)
+		states[i], err = signers[i].NewState()
+		require.NoError(t, err)
+		commitment, err := states[i].Commit()
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddCommitment(commitment))
+	}
+
+	request, err := frost.NewBlindRequest(c, keygen.GroupPublicKey, token, nil)
+	require.NoError(t, err)
+	challenge, err := request.Blind(coordinator.Commitments())
+	require.NoError(t, err)
+
+	coordinator.BlindChallenge = challenge
+	pkg, err := coordinator.SigningPackage(keygen.Participants[0].Identifier, keygen.Participants[1].Identifier)
+	require.NoError(t, err)
+	received, err := frost.SigningPackageFromBytes(pkg.Bytes())
+	require.NoError(t, err)
+	require.True(t, received.BlindChallenge.Equal(challenge))
+
+	// Only one session per signer may be open at a time
+	_, err = signers[0].NewState()
+	require.ErrorIs(t, err, frost.ErrTooManyBlindSessions)
+
+	// A blind signer needs a blind challenge
+	unblinded := *received
+	unblinded.BlindChallenge = nil
+	_, err = states[0].SignPackage(&unblinded)
+	require.Error(t, err)
+
+	for i, state := range states {
+		share, err := state.SignPackage(received)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddSignatureShare(share))
+		require.Zero(t, signers[i].OpenSessions())
+	}
+	blinded, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	require.False(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), token, blinded.Bytes()))
+
+	sig, err := request.Unblind(blinded)
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), token, sig.Bytes()))
+
+	// Nothing the signers saw appears in the final signature
+	require.False(t, sig.R.Equal(blinded.R))
+	require.False(t, sig.Z.Equal(blinded.Z))
+
+	_, err = request.Unblind(&frost.Signature{R: blinded.R, Z: frost.NewScalar()})
+	require.Error(t, err)
+}
+
+// A coordinator cannot slip a blind challenge past an ordinary signer, which
+// would let it choose the message that is signed.
+func TestBlindChallengeRequiresOptIn(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	message := []byte("ordinary message")
+	forged := []byte("forged message")
+
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+	require.NoError(t, err)
+	states := make([]*frost.State, 2)
+	for i := range states {
+		states[i] = frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitment, err := states[i].Commit()
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddCommitment(commitment))
+	}
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+
+	request, err := frost.NewBlindRequest(c, keygen.GroupPublicKey, forged, nil)
+	require.NoError(t, err)
+	evil := *pkg
+	evil.BlindChallenge, err = request.Blind(pkg.Commitments)
+	require.NoError(t, err)
+	for _, state := range states {
+		_, err = state.SignPackage(&evil)
+		require.Error(t, err)
+		require.Error(t, state.SetBlindChallenge(evil.BlindChallenge))
+	}
+
+	// The refusal left the nonces intact
+	for _, state := range states {
+		share, err := state.SignPackage(pkg)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddSignatureShare(share))
+	}
+	sig, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+
+	_, err = frost.NewBlindSigner(c, keygen.Participants, keygen.GroupPublicKey, keygen.ParticipantPrivateKeys[0], 0)
+	require.Error(t, err)
+}
+
+// A blind session is freed when its State is closed, or when the Policy
+// refuses it, so abandoned and refused requests cannot use up the signer.
+func TestBlindSessionRelease(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	signer, err := frost.NewBlindSigner(c, keygen.Participants, keygen.GroupPublicKey, keygen.ParticipantPrivateKeys[0], 1)
+	require.NoError(t, err)
+
+	// Abandoned by the requester
+	state, err := signer.NewState()
+	require.NoError(t, err)
+	_, err = state.Commit()
+	require.NoError(t, err)
+	require.Equal(t, 1, signer.OpenSessions())
+	state.Close()
+	state.Close()
+	require.Zero(t, signer.OpenSessions())
+
+	// Refused by the Policy
+	state, err = signer.NewState()
+	require.NoError(t, err)
+	state.Policy = frost.PolicyFunc(func(*frost.SigningRequest) error { return errors.New("no") })
+	mine, err := state.Commit()
+	require.NoError(t, err)
+	other, err := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, nil, keygen.ParticipantPrivateKeys[1]).Commit()
+	require.NoError(t, err)
+	request, err := frost.NewBlindRequest(c, keygen.GroupPublicKey, []byte("token"), nil)
+	require.NoError(t, err)
+	challenge, err := request.Blind([]*frost.Commitment{mine, other})
+	require.NoError(t, err)
+	pkg := &frost.SigningPackage{Commitments: []*frost.Commitment{mine, other}, BlindChallenge: challenge}
+	_, err = state.SignPackage(pkg)
+	require.ErrorIs(t, err, frost.ErrRefused)
+	require.Zero(t, signer.OpenSessions())
+	_, err = state.SignPackage(pkg)
+	require.Error(t, err)
+
+	_, err = signer.NewState()
+	require.NoError(t, err)
+}
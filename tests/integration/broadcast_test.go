@@ -0,0 +1,161 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+// Run a broadcast ceremony among the chosen signers. If tamper is set, it may
+// replace a share before it is broadcast.
+func broadcastCeremony(t *testing.T, keygen *frost.KeygenOutput, threshold int, msg []byte, chosen []int, tamper func(*frost.SignatureShare) *frost.SignatureShare) ([]*frost.Signature, []error) {
+	t.Helper()
+	c := frost.DefaultCiphersuite()
+	ids := make([]*frost.Scalar, len(chosen))
+	for n, i := range chosen {
+		ids[n] = keygen.Participants[i].Identifier
+	}
+
+	peers := make([]*frost.BroadcastSigner, len(chosen))
+	commitments := make([]*frost.Commitment, len(chosen))
+	for n, i := range chosen {
+		state := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[i])
+		var err error
+		peers[n], err = frost.NewBroadcastSigner(state, threshold, ids...)
+		require.NoError(t, err)
+		commitments[n], err = peers[n].Commit()
+		require.NoError(t, err)
+	}
+	// Deliver in a different order to each peer
+	for n, peer := range peers {
+		for k := range commitments {
+			com := commitments[(n+k)%len(commitments)]
+			require.NoError(t, peer.AddCommitment(com))
+		}
+		require.Empty(t, peer.MissingCommitments())
+	}
+
+	shares := make([]*frost.SignatureShare, len(chosen))
+	for n, peer := range peers {
+		var err error
+		shares[n], err = peer.Sign()
+		require.NoError(t, err)
+		if tamper != nil {
+			shares[n] = tamper(shares[n])
+		}
+	}
+	sigs := make([]*frost.Signature, len(peers))
+	errs := make([]error, len(peers))
+	for n, peer := range peers {
+		for k := range shares {
+			if k != n {
+				require.NoError(t, peer.AddSignatureShare(shares[k]))
+			}
+		}
+		sigs[n], errs[n] = peer.Aggregate()
+	}
+	return sigs, errs
+}
+
+func TestBroadcastSigning(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(5, 3)
+	require.NoError(t, err)
+	msg := []byte("peer to peer")
+
+	sigs, errs := broadcastCeremony(t, keygen, 3, msg, []int{4, 0, 2}, nil)
+	for n := range sigs {
+		require.NoError(t, errs[n])
+		require.Equal(t, sigs[0].Bytes(), sigs[n].Bytes())
+	}
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), msg, sigs[0].Bytes()))
+}
+
+func TestBroadcastSigningCulprits(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(5, 4)
+	require.NoError(t, err)
+	bad := []*frost.Scalar{keygen.Participants[1].Identifier, keygen.Participants[3].Identifier}
+
+	tamper := func(share *frost.SignatureShare) *frost.SignatureShare {
+		for _, id := range bad {
+			if id.Equal(share.Identifier) {
+				return &frost.SignatureShare{Identifier: share.Identifier, Share: frost.NewScalar().Add(share.Share, share.Share)}
+			}
+		}
+		return share
+	}
+	chosen := []int{3, 2, 1, 0}
+	_, errs := broadcastCeremony(t, keygen, 4, []byte("blame"), chosen, tamper)
+
+	// Every honest peer names the same culprits
+	for n, err := range errs {
+		if chosen[n] == 1 || chosen[n] == 3 {
+			continue
+		}
+		require.ErrorIs(t, err, frost.ErrInvalidShare)
+		var culprits *frost.CulpritError
+		require.True(t, errors.As(err, &culprits))
+		require.Len(t, culprits.Culprits, 2)
+		require.True(t, culprits.Culprits[0].Equal(bad[0]))
+		require.True(t, culprits.Culprits[1].Equal(bad[1]))
+	}
+}
+
+func TestBroadcastSignerRejects(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	p := keygen.Participants
+	newState := func(i int) *frost.State {
+		return frost.NewState(c, p, keygen.GroupPublicKey, []byte("m"), keygen.ParticipantPrivateKeys[i])
+	}
+
+	_, err = frost.NewBroadcastSigner(newState(0), 2, p[1].Identifier, p[2].Identifier)
+	require.Error(t, err, "signing set without this signer")
+	_, err = frost.NewBroadcastSigner(newState(0), 2, p[0].Identifier, p[0].Identifier)
+	require.Error(t, err, "duplicate signer")
+	_, err = frost.NewBroadcastSigner(newState(0), 2, p[0].Identifier)
+	require.Error(t, err, "fewer signers than the threshold")
+	_, err = frost.NewBroadcastSigner(newState(0), 4, p[0].Identifier, p[1].Identifier, p[2].Identifier)
+	require.Error(t, err, "threshold above the number of participants")
+
+	a, err := frost.NewBroadcastSigner(newState(0), 2, p[0].Identifier, p[1].Identifier)
+	require.NoError(t, err)
+	_, err = a.Commit()
+	require.NoError(t, err)
+	_, err = a.Sign()
+	require.Error(t, err, "commitments missing")
+
+	other, err := newState(2).Commit()
+	require.NoError(t, err)
+	require.Error(t, a.AddCommitment(other), "outside the signing set")
+
+	b := newState(1)
+	first, err := b.Commit()
+	require.NoError(t, err)
+	second, err := newState(1).Commit()
+	require.NoError(t, err)
+	require.NoError(t, a.AddCommitment(first))
+	require.NoError(t, a.AddCommitment(first), "rebroadcast")
+	require.Error(t, a.AddCommitment(second), "equivocation")
+}
+
+// A signing set below the key's real threshold produces valid shares but an
+// invalid signature, which Aggregate must not return.
+func TestBroadcastSignerVerifiesAggregate(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 3)
+	require.NoError(t, err)
+	msg := []byte("misconfigured threshold")
+	sigs, errs := broadcastCeremony(t, keygen, 2, msg, []int{0, 1}, nil)
+	for n := range sigs {
+		require.Error(t, errs[n])
+		require.NotErrorIs(t, errs[n], frost.ErrInvalidShare)
+		require.Nil(t, sigs[n])
+	}
+}
@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinator(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(5, 3)
+	require.NoError(t, err)
+	message := []byte("coordinated")
+
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 3, message)
+	require.NoError(t, err)
+	_, err = coordinator.SigningPackage()
+	require.Error(t, err, "no commitments yet")
+
+	states := make([]*frost.State, 5)
+	for i := range states {
+		states[i] = frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitment, err := states[i].Commit()
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddCommitment(commitment))
+		require.Error(t, coordinator.AddCommitment(commitment), "duplicate commitment")
+	}
+	require.Len(t, coordinator.Commitments(), 5)
+
+	// Choose signers 1, 3 and 4
+	chosen := []int{0, 2, 3}
+	pkg, err := coordinator.SigningPackage(
+		keygen.Participants[0].Identifier,
+		keygen.Participants[2].Identifier,
+		keygen.Participants[3].Identifier,
+	)
+	require.NoError(t, err)
+	require.Len(t, pkg.Commitments, 3)
+	require.Len(t, coordinator.Missing(), 3)
+
+	_, err = coordinator.Aggregate()
+	require.Error(t, err)
+
+	// A signer outside the signing set cannot produce a share
+	outsider, err := states[1].Sign(pkg.Commitments)
+	require.Error(t, err)
+	require.Nil(t, outsider)
+
+	for n, i := range chosen {
+		share, err := states[i].Sign(pkg.Commitments)
+		require.NoError(t, err)
+		if n == 0 {
+			bad := &frost.SignatureShare{Identifier: share.Identifier, Share: frost.NewScalar().Add(share.Share, share.Share)}
+			require.ErrorIs(t, coordinator.AddSignatureShare(bad), frost.ErrInvalidShare)
+		}
+		require.NoError(t, coordinator.AddSignatureShare(share))
+		require.Error(t, coordinator.AddSignatureShare(share), "duplicate share")
+		require.Len(t, coordinator.Missing(), 2-n)
+	}
+
+	sig, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+}
+
+func TestCoordinatorDefaultSigningSet(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(4, 2)
+	require.NoError(t, err)
+	message := []byte("first come, first served")
+
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+	require.NoError(t, err)
+	states := make([]*frost.State, 3)
+	for i := range states {
+		states[i] = frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitment, err := states[i].Commit()
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddCommitment(commitment))
+	}
+
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+	require.Len(t, pkg.Commitments, 2)
+	require.Error(t, coordinator.AddCommitment(&frost.Commitment{}), "signing set is fixed")
+
+	for _, state := range states[:2] {
+		share, err := state.Sign(pkg.Commitments)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddSignatureShare(share))
+	}
+	sig, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+}
+
+func TestCoordinatorThresholdRange(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+
+	for _, threshold := range []int{-1, 0, 4} {
+		_, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, threshold, nil)
+		require.Error(t, err)
+		_, err = frost.NewBatchCoordinator(c, keygen.Participants, keygen.GroupPublicKey, threshold, [][]byte{nil})
+		require.Error(t, err)
+	}
+	_, err = frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 3, nil)
+	require.NoError(t, err)
+}
@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivedChildKeySigning(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	parent := keygen.GroupPublicKey
+	path := [][]byte{[]byte("releases"), []byte("2026")}
+
+	// Child keys only need the parent key, and paths compose
+	child := frost.DeriveChildKey(parent, path...)
+	require.Equal(t, child.Bytes(), frost.DeriveChildKey(frost.DeriveChildKey(parent, path[0]), path[1]).Bytes())
+	require.NotEqual(t, child.Bytes(), frost.DeriveChildKey(parent, []byte("releases2026")).Bytes())
+	require.NotEqual(t, child.Bytes(), parent.Bytes())
+
+	sign := func(message []byte, randomize bool) (*frost.GroupKey, *frost.Signature) {
+		coordinator, err := frost.NewCoordinator(c, keygen.Participants, parent, 2, message)
+		require.NoError(t, err)
+		coordinator.DerivationPath = path
+		if randomize {
+			coordinator.RandomizerSeed, err = frost.NewRandomizerSeed(nil)
+			require.NoError(t, err)
+		}
+		states := make([]*frost.State, 2)
+		for i := range states {
+			states[i] = frost.NewState(c, keygen.Participants, parent, message, keygen.ParticipantPrivateKeys[i])
+			commitment, err := states[i].Commit()
+			require.NoError(t, err)
+			require.NoError(t, coordinator.AddCommitment(commitment))
+		}
+		pkg, err := coordinator.SigningPackage()
+		require.NoError(t, err)
+		received, err := frost.SigningPackageFromBytes(pkg.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, path, received.DerivationPath)
+		require.Equal(t, pkg.Hash(), received.Hash())
+
+		for _, state := range states {
+			share, err := state.SignPackage(received)
+			require.NoError(t, err)
+			require.NoError(t, coordinator.AddSignatureShare(share))
+		}
+		sig, err := coordinator.Aggregate()
+		require.NoError(t, err)
+		return states[0].EffectiveGroupKey(), sig
+	}
+
+	message := []byte("child signature")
+	key, sig := sign(message, false)
+	require.Equal(t, child.Bytes(), key.Bytes())
+	require.True(t, ed25519.Verify(child.Bytes(), message, sig.Bytes()))
+	require.False(t, ed25519.Verify(parent.Bytes(), message, sig.Bytes()))
+
+	// Derivation and re-randomization compose
+	key, sig = sign(message, true)
+	require.NotEqual(t, child.Bytes(), key.Bytes())
+	require.True(t, ed25519.Verify(key.Bytes(), message, sig.Bytes()))
+}
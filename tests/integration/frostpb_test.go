@@ -0,0 +1,117 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/frostpb"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestProtobufCeremony(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	msg := []byte("protobuf")
+
+	// Each signer only receives its key package
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, msg)
+	require.NoError(t, err)
+	states := make([]*frost.State, 2)
+	for i := range states {
+		kp, err := frostpb.UnmarshalKeyPackage(frostpb.NewKeyPackage(keygen, i).Marshal())
+		require.NoError(t, err)
+		require.Len(t, kp.Participants, 3)
+		require.Len(t, kp.VssCommitment, 2)
+		states[i] = frost.NewState(c, kp.Participants, kp.GroupKey, msg, kp.SecretShare)
+
+		com, err := states[i].Commit()
+		require.NoError(t, err)
+		enc, err := frostpb.MarshalMessage(&frost.Message{Type: frost.MessageTypeCommitment, From: com.Identifier, Commitment: com})
+		require.NoError(t, err)
+		decoded, err := frostpb.UnmarshalMessage(enc)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddCommitment(decoded.Commitment))
+	}
+
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+	for _, s := range states {
+		decoded, err := frostpb.UnmarshalSigningPackage(frostpb.MarshalSigningPackage(pkg))
+		require.NoError(t, err)
+		require.Equal(t, pkg.Bytes(), decoded.Bytes())
+		share, err := s.SignPackage(decoded)
+		require.NoError(t, err)
+		share, err = frostpb.UnmarshalSignatureShare(frostpb.MarshalSignatureShare(share))
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddSignatureShare(share))
+	}
+	sig, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	sig, err = frostpb.UnmarshalSignature(frostpb.MarshalSignature(sig))
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), msg, sig.Bytes()))
+}
+
+func TestProtobufWireFormat(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	state := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, []byte("m"), keygen.ParticipantPrivateKeys[0])
+	com, err := state.Commit()
+	require.NoError(t, err)
+
+	// Fields 1, 2 and 3 as length-delimited 32-byte strings
+	enc := frostpb.MarshalCommitment(com)
+	require.Len(t, enc, 3*34)
+	require.Equal(t, []byte{0x0a, 0x20}, enc[:2])
+	require.Equal(t, []byte{0x12, 0x20}, enc[34:36])
+	require.Equal(t, []byte{0x1a, 0x20}, enc[68:70])
+
+	// Fields in another order, plus unknown fields, decode the same
+	reordered := append(append(append([]byte{}, enc[68:]...), enc[34:68]...), enc[:34]...)
+	reordered = protowire.AppendTag(reordered, 99, protowire.VarintType)
+	reordered = protowire.AppendVarint(reordered, 7)
+	decoded, err := frostpb.UnmarshalCommitment(reordered)
+	require.NoError(t, err)
+	require.Equal(t, enc, frostpb.MarshalCommitment(decoded))
+
+	// A missing field, a wrong wire type and a truncated message are rejected
+	_, err = frostpb.UnmarshalCommitment(enc[:68])
+	require.Error(t, err)
+	bad := append([]byte{0x08, 0x01}, enc[2:]...)
+	_, err = frostpb.UnmarshalCommitment(bad)
+	require.Error(t, err)
+	_, err = frostpb.UnmarshalCommitment(enc[:len(enc)-1])
+	require.Error(t, err)
+
+	// The envelope's payload must match its type
+	share := &frost.SignatureShare{Identifier: com.Identifier, Share: frost.NewScalar()}
+	env, err := frostpb.MarshalMessage(&frost.Message{Type: frost.MessageTypeSignatureShare, From: com.Identifier, SignatureShare: share})
+	require.NoError(t, err)
+	decodedMsg, err := frostpb.UnmarshalMessage(env)
+	require.NoError(t, err)
+	require.True(t, decodedMsg.SignatureShare.Share.Equal(frost.NewScalar()))
+	env[1] = byte(frost.MessageTypeCommitment)
+	_, err = frostpb.UnmarshalMessage(env)
+	require.Error(t, err)
+
+	// DKG messages travel as opaque payloads
+	round2 := &frostpb.DKGRound2{Identifier: com.Identifier, SigningShare: keygen.ParticipantPrivateKeys[1].Scalar}
+	env, err = frostpb.MarshalMessage(&frost.Message{Type: frost.MessageTypeKeyGen2, From: com.Identifier, To: keygen.Participants[1].Identifier, Payload: round2.Marshal()})
+	require.NoError(t, err)
+	decodedMsg, err = frostpb.UnmarshalMessage(env)
+	require.NoError(t, err)
+	got, err := frostpb.UnmarshalDKGRound2(decodedMsg.Payload)
+	require.NoError(t, err)
+	require.True(t, got.SigningShare.Equal(round2.SigningShare))
+
+	proof := &frost.Signature{R: com.Hiding, Z: keygen.ParticipantPrivateKeys[0].Scalar}
+	round1 := &frostpb.DKGRound1{Identifier: com.Identifier, Commitment: keygen.VssCommitment, ProofOfKnowledge: proof}
+	got1, err := frostpb.UnmarshalDKGRound1(round1.Marshal())
+	require.NoError(t, err)
+	require.Equal(t, round1.Marshal(), got1.Marshal())
+}
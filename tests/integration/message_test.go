@@ -0,0 +1,151 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+// Send a message over a pretend wire, alternating binary and JSON.
+func transmit(t *testing.T, msg *frost.Message, asJSON bool) []byte {
+	t.Helper()
+	if asJSON {
+		j, err := msg.EncodeJSON()
+		require.NoError(t, err)
+		decoded, err := frost.MessageFromJSON(j)
+		require.NoError(t, err)
+		msg = decoded
+	}
+	b, err := msg.EncodeBinary()
+	require.NoError(t, err)
+	return b
+}
+
+func TestDispatcher(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	msg := []byte("dispatched")
+	sid := []byte("session-1")
+
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, msg)
+	require.NoError(t, err)
+	coordinator.SessionID = sid
+	hub := frost.NewDispatcher(nil)
+	hub.Register(sid, frost.CoordinatorHandler(coordinator))
+
+	signers := make([]*frost.Dispatcher, 2)
+	for i := range signers {
+		state := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[i])
+		signers[i] = frost.NewDispatcher(state.MyIdentifier)
+		signers[i].Register(sid, frost.SignerHandler(state))
+
+		com, err := state.Commit()
+		require.NoError(t, err)
+		wire := transmit(t, &frost.Message{Type: frost.MessageTypeCommitment, SessionID: sid, From: com.Identifier, Commitment: com}, i == 0)
+		reply, err := hub.DispatchBinary(wire)
+		require.NoError(t, err)
+		require.Nil(t, reply)
+	}
+
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+	for i, signer := range signers {
+		wire := transmit(t, &frost.Message{Type: frost.MessageTypeSigningPackage, SessionID: sid, SigningPackage: pkg}, i == 1)
+		reply, err := signer.DispatchBinary(wire)
+		require.NoError(t, err)
+		require.Equal(t, frost.MessageTypeSignatureShare, reply.Type)
+		_, err = hub.DispatchBinary(transmit(t, reply, false))
+		require.NoError(t, err)
+	}
+
+	sig, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), msg, sig.Bytes()))
+}
+
+func TestDispatcherRejects(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	p := keygen.Participants
+	state := frost.NewState(c, p, keygen.GroupPublicKey, []byte("m"), keygen.ParticipantPrivateKeys[0])
+	com, err := state.Commit()
+	require.NoError(t, err)
+
+	d := frost.NewDispatcher(p[0].Identifier)
+	d.Register([]byte("s"), frost.SignerHandler(state))
+
+	// Unknown session, wrong recipient, and a payload from someone else
+	_, err = d.Dispatch(&frost.Message{Type: frost.MessageTypeCommitment, SessionID: []byte("t"), From: com.Identifier, Commitment: com})
+	require.ErrorIs(t, err, frost.ErrUnknownSession)
+	_, err = d.Dispatch(&frost.Message{Type: frost.MessageTypeCommitment, SessionID: []byte("s"), From: com.Identifier, To: p[1].Identifier, Commitment: com})
+	require.ErrorIs(t, err, frost.ErrWrongRecipient)
+	_, err = (&frost.Message{Type: frost.MessageTypeCommitment, From: p[1].Identifier, Commitment: com}).EncodeBinary()
+	require.Error(t, err)
+	_, err = (&frost.Message{Type: frost.MessageTypeSignatureShare, From: p[0].Identifier, Commitment: com}).EncodeBinary()
+	require.Error(t, err)
+
+	// A signer does not accept commitments
+	_, err = d.Dispatch(&frost.Message{Type: frost.MessageTypeCommitment, SessionID: []byte("s"), From: com.Identifier, Commitment: com})
+	require.Error(t, err)
+
+	// DKG rounds need a DKG handler
+	round1 := &frost.Message{Type: frost.MessageTypeKeyGen1, From: p[1].Identifier, Payload: []byte("opaque")}
+	_, err = d.Dispatch(round1)
+	require.Error(t, err)
+	var got []byte
+	d.RegisterDKG(frost.HandlerFunc(func(msg *frost.Message) (*frost.Message, error) {
+		got = msg.Payload
+		return nil, nil
+	}))
+	_, err = d.DispatchBinary(transmit(t, round1, true))
+	require.NoError(t, err)
+	require.Equal(t, []byte("opaque"), got)
+
+	// Trailing bytes
+	wire := transmit(t, round1, false)
+	_, err = frost.MessageFromBinary(append(wire, 0))
+	require.Error(t, err)
+
+	// A zero sender or recipient in a JSON envelope
+	j, err := round1.EncodeJSON()
+	require.NoError(t, err)
+	_, err = frost.MessageFromJSON([]byte(strings.Replace(string(j), base64.URLEncoding.EncodeToString(p[1].Identifier.Bytes()), base64.URLEncoding.EncodeToString(make([]byte, 32)), 1)))
+	require.Error(t, err)
+	_, err = frost.MessageFromJSON([]byte(strings.Replace(string(j), `"p":`, `"to":"`+base64.URLEncoding.EncodeToString(make([]byte, 32))+`","p":`, 1)))
+	require.Error(t, err)
+
+	// A signing package without a session ID, sent in a session
+	coordinator, err := frost.NewCoordinator(c, p, keygen.GroupPublicKey, 2, []byte("m"))
+	require.NoError(t, err)
+	require.NoError(t, coordinator.AddCommitment(com))
+	other, err := frost.NewState(c, p, keygen.GroupPublicKey, []byte("m"), keygen.ParticipantPrivateKeys[1]).Commit()
+	require.NoError(t, err)
+	require.NoError(t, coordinator.AddCommitment(other))
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+	require.Empty(t, pkg.SessionID)
+	_, err = d.Dispatch(&frost.Message{Type: frost.MessageTypeSigningPackage, SessionID: []byte("s"), SigningPackage: pkg})
+	require.Error(t, err)
+}
+
+// Handlers run without the dispatcher's lock, so they can use the dispatcher.
+func TestDispatcherReentrant(t *testing.T) {
+	d := frost.NewDispatcher(nil)
+	sid := []byte("once")
+	d.Register(sid, frost.HandlerFunc(func(msg *frost.Message) (*frost.Message, error) {
+		d.Unregister(msg.SessionID)
+		return nil, nil
+	}))
+	msg := &frost.Message{Type: frost.MessageTypeSigningPackage, SessionID: sid, SigningPackage: &frost.SigningPackage{SessionID: sid}}
+	_, err := d.Dispatch(msg)
+	require.NoError(t, err)
+	_, err = d.Dispatch(msg)
+	require.ErrorIs(t, err, frost.ErrUnknownSession)
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/noncestore"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRestoreState(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	message := []byte("signed after lunch")
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	require.NoError(t, err)
+
+	states := make([]*frost.State, 2)
+	commitments := make([]*frost.Commitment, 2)
+	for i := range states {
+		states[i] = frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitments[i], err = states[i].Commit()
+		require.NoError(t, err)
+	}
+
+	// Signer 0 goes offline between rounds
+	blob, err := states[0].Export(key)
+	require.NoError(t, err)
+	_, err = states[0].Sign(commitments)
+	require.Error(t, err, "the exported nonce must leave the live state")
+
+	dir := t.TempDir()
+	guard, err := noncestore.NewFileStore(dir)
+	require.NoError(t, err)
+
+	// Wrong key, wrong participant and tampering are all rejected
+	_, err = frost.RestoreState(c, blob, make([]byte, 32), keygen.ParticipantPrivateKeys[0], guard)
+	require.Error(t, err)
+	_, err = frost.RestoreState(c, blob, key, keygen.ParticipantPrivateKeys[1], guard)
+	require.Error(t, err)
+	tampered := append([]byte{}, blob...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = frost.RestoreState(c, tampered, key, keygen.ParticipantPrivateKeys[0], guard)
+	require.Error(t, err)
+
+	restored, err := frost.RestoreState(c, blob, key, keygen.ParticipantPrivateKeys[0], guard)
+	require.NoError(t, err)
+	require.True(t, restored.MyCommitment.Hiding.Equal(commitments[0].Hiding))
+	require.Equal(t, message, restored.Message)
+
+	shares := make([]*frost.SignatureShare, 2)
+	shares[0], err = restored.Sign(commitments)
+	require.NoError(t, err)
+	shares[1], err = states[1].Sign(commitments)
+	require.NoError(t, err)
+
+	// Restoring the same file again, even from a fresh process, fails
+	guard, err = noncestore.NewFileStore(dir)
+	require.NoError(t, err)
+	_, err = frost.RestoreState(c, blob, key, keygen.ParticipantPrivateKeys[0], guard)
+	require.ErrorIs(t, err, frost.ErrNonceReused)
+
+	aggState := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, nil)
+	_, err = aggState.Sign(commitments)
+	require.NoError(t, err)
+	sig, err := aggState.Aggregate(shares)
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+}
+
+// The export has no room for the optional features, so a State using any of
+// them is refused, and keeps its nonce.
+func TestExportRefusesExtendedState(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	key := make([]byte, 32)
+	share := keygen.ParticipantPrivateKeys[0]
+	newState := func() *frost.State {
+		s := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, []byte("m"), share)
+		_, err := s.Commit()
+		require.NoError(t, err)
+		return s
+	}
+
+	withSession := newState()
+	withSession.SessionID = []byte("session")
+	withRandomizer := newState()
+	require.NoError(t, withRandomizer.SetRandomizer(keygen.ParticipantPrivateKeys[2].Scalar))
+	withPath := newState()
+	require.NoError(t, withPath.SetDerivationPath([]byte("m/0")))
+	withAdaptor := newState()
+	require.NoError(t, withAdaptor.SetAdaptorPoint(keygen.Participants[2].PublicKeyShare))
+	signer, err := frost.NewBlindSigner(c, keygen.Participants, keygen.GroupPublicKey, share, 1)
+	require.NoError(t, err)
+	blind, err := signer.NewState()
+	require.NoError(t, err)
+	_, err = blind.Commit()
+	require.NoError(t, err)
+
+	for _, s := range []*frost.State{withSession, withRandomizer, withPath, withAdaptor, blind} {
+		_, err := s.Export(key)
+		require.Error(t, err)
+	}
+	require.Equal(t, 1, signer.OpenSessions())
+	withSession.SessionID = nil
+	_, err = withSession.Export(key)
+	require.NoError(t, err)
+}
@@ -0,0 +1,215 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyRefusal(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(4, 2)
+	require.NoError(t, err)
+	p := keygen.Participants
+
+	policy := frost.AllOf(
+		frost.MaxMessageSize(32),
+		frost.AllowPrefixes([]byte("tx:"), []byte("memo:")),
+		frost.RequireCoSigners(p[3].Identifier),
+	)
+	sign := func(msg []byte, signers ...int) (*frost.Signature, error) {
+		coordinator, err := frost.NewCoordinator(c, p, keygen.GroupPublicKey, 2, msg)
+		require.NoError(t, err)
+		states := make([]*frost.State, len(signers))
+		for n, i := range signers {
+			states[n] = frost.NewState(c, p, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[i])
+			states[n].Policy = policy
+			commitment, err := states[n].Commit()
+			require.NoError(t, err)
+			require.NoError(t, coordinator.AddCommitment(commitment))
+		}
+		pkg, err := coordinator.SigningPackage()
+		require.NoError(t, err)
+		for _, s := range states {
+			share, err := s.SignPackage(pkg)
+			if err != nil {
+				// A refusal leaves the nonce unused, so the State can still sign
+				// once the policy allows it
+				_, retry := s.SignPackage(pkg)
+				require.ErrorIs(t, retry, frost.ErrRefused)
+				s.Policy = nil
+				share, retry := s.SignPackage(pkg)
+				require.NoError(t, retry)
+				require.NoError(t, coordinator.AddSignatureShare(share))
+				return nil, err
+			}
+			require.NoError(t, coordinator.AddSignatureShare(share))
+		}
+		return coordinator.Aggregate()
+	}
+
+	sig, err := sign([]byte("tx:pay bob 5"), 0, 3)
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), []byte("tx:pay bob 5"), sig.Bytes()))
+
+	for _, tc := range []struct {
+		name    string
+		msg     []byte
+		signers []int
+		policy  string
+	}{
+		{"prefix", []byte("evil:pay mallory"), []int{0, 3}, "prefix"},
+		{"size", []byte("memo:this message is far too long to sign"), []int{0, 3}, "size"},
+		{"cosigners", []byte("tx:pay bob 5"), []int{0, 1}, "cosigners"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := sign(tc.msg, tc.signers...)
+			var refusal *frost.RefusalError
+			require.True(t, errors.As(err, &refusal))
+			require.Equal(t, tc.policy, refusal.Policy)
+			require.True(t, refusal.Signer.Equal(p[tc.signers[0]].Identifier))
+		})
+	}
+}
+
+func TestDailyQuota(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC)
+	quota := frost.DailyQuota(2, func() time.Time { return now })
+	req := func(path ...[]byte) *frost.SigningRequest {
+		return &frost.SigningRequest{GroupKey: keygen.GroupPublicKey, DerivationPath: path}
+	}
+
+	require.NoError(t, quota.Approve(req()))
+	require.NoError(t, quota.Approve(req()))
+	require.ErrorIs(t, quota.Approve(req()), frost.ErrRefused)
+
+	// A child key has its own quota
+	require.NoError(t, quota.Approve(req([]byte("child"))))
+
+	// The quota resets at midnight UTC
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, quota.Approve(req()))
+}
+
+func TestBatchPolicyReleasesNothing(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	msgs := [][]byte{[]byte("tx:one"), []byte("bad:two")}
+
+	batch, err := frost.NewBatchCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, msgs)
+	require.NoError(t, err)
+	signers := make([]*frost.BatchState, 2)
+	for i := range signers {
+		signers[i] = frost.NewBatchState(c, keygen.Participants, keygen.GroupPublicKey, msgs, keygen.ParticipantPrivateKeys[i])
+		for _, s := range signers[i].States {
+			s.Policy = frost.AllowPrefixes([]byte("tx:"))
+		}
+		commitments, err := signers[i].Commit()
+		require.NoError(t, err)
+		require.NoError(t, batch.AddCommitments(commitments))
+	}
+	pkgs, err := batch.SigningPackages()
+	require.NoError(t, err)
+
+	shares, err := signers[0].SignPackages(pkgs)
+	require.ErrorIs(t, err, frost.ErrRefused)
+	require.Nil(t, shares)
+}
+
+func TestPolicySeesWholeRequest(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	message := []byte("adaptor swap")
+	path := [][]byte{[]byte("child")}
+	adaptor := frost.NewElement().Mul(keygen.ParticipantPrivateKeys[2].Scalar, nil)
+
+	var seen []*frost.SigningRequest
+	refuse := true
+	policy := frost.PolicyFunc(func(req *frost.SigningRequest) error {
+		seen = append(seen, req)
+		if refuse && len(req.DerivationPath) > 0 {
+			return &frost.RefusalError{Policy: "paths", Reason: "no child keys"}
+		}
+		return nil
+	})
+
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+	require.NoError(t, err)
+	coordinator.DerivationPath = path
+	coordinator.AdaptorPoint = adaptor
+	coordinator.RandomizerSeed, err = frost.NewRandomizerSeed(nil)
+	require.NoError(t, err)
+	state := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[0])
+	state.Policy = policy
+	com, err := state.Commit()
+	require.NoError(t, err)
+	require.NoError(t, coordinator.AddCommitment(com))
+	other := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[1])
+	com, err = other.Commit()
+	require.NoError(t, err)
+	require.NoError(t, coordinator.AddCommitment(com))
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+
+	_, err = state.SignPackage(pkg)
+	require.ErrorIs(t, err, frost.ErrRefused)
+	require.Len(t, seen, 1)
+	require.True(t, seen[0].Randomizer.Equal(pkg.Randomizer))
+	require.True(t, seen[0].AdaptorPoint.Equal(adaptor))
+	require.Equal(t, path, seen[0].DerivationPath)
+	require.False(t, seen[0].Blind)
+
+	// The refused package was not applied to the State
+	require.Equal(t, keygen.GroupPublicKey.Bytes(), state.EffectiveGroupKey().Bytes())
+
+	refuse = false
+	_, err = state.SignPackage(pkg)
+	require.NoError(t, err)
+	require.NotEqual(t, keygen.GroupPublicKey.Bytes(), state.EffectiveGroupKey().Bytes())
+}
+
+func TestBatchQuotaCountsWholeBatch(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	quota := frost.DailyQuota(2, nil)
+	req := &frost.SigningRequest{GroupKey: keygen.GroupPublicKey}
+
+	batchSign := func(msgs ...[]byte) error {
+		batch, err := frost.NewBatchCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, msgs)
+		require.NoError(t, err)
+		signers := make([]*frost.BatchState, 2)
+		for i := range signers {
+			signers[i] = frost.NewBatchState(c, keygen.Participants, keygen.GroupPublicKey, msgs, keygen.ParticipantPrivateKeys[i])
+			commitments, err := signers[i].Commit()
+			require.NoError(t, err)
+			require.NoError(t, batch.AddCommitments(commitments))
+		}
+		for _, s := range signers[0].States {
+			s.Policy = frost.AllOf(quota, frost.AllowPrefixes([]byte("tx:")))
+		}
+		pkgs, err := batch.SigningPackages()
+		require.NoError(t, err)
+		_, err = signers[0].SignPackages(pkgs)
+		return err
+	}
+
+	// A batch refused by another policy uses none of the quota
+	require.ErrorIs(t, batchSign([]byte("tx:one"), []byte("bad:two")), frost.ErrRefused)
+	// A batch larger than the quota uses none of it either
+	require.ErrorIs(t, batchSign([]byte("tx:one"), []byte("tx:two"), []byte("tx:three")), frost.ErrRefused)
+
+	require.NoError(t, batchSign([]byte("tx:one"), []byte("tx:two")))
+	require.ErrorIs(t, quota.Approve(req), frost.ErrRefused)
+}
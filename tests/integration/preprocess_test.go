@@ -0,0 +1,191 @@
+package integration
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/noncestore"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreprocessedSigning(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+
+	// Each signer preprocesses a pool of nonces ahead of time
+	key := bytes.Repeat([]byte{7}, noncestore.KeySize)
+	dirs := make([]string, 2)
+	pools := make([][]*frost.Commitment, 2)
+	for i := range dirs {
+		dirs[i] = t.TempDir()
+		store, err := noncestore.NewEncryptedFileStore(dirs[i], key)
+		require.NoError(t, err)
+		pools[i], err = frost.Preprocess(c, keygen.ParticipantPrivateKeys[i], 5, store)
+		require.NoError(t, err)
+		n, err := store.Len()
+		require.NoError(t, err)
+		require.Equal(t, 5, n)
+	}
+
+	// The coordinator picks one published commitment per signer, and sends
+	// it along with the message: signing then takes a single round.
+	message := []byte("one round")
+	commitments := []*frost.Commitment{pools[0][2], pools[1][4]}
+	shares := make([]*frost.SignatureShare, 2)
+	for i := range shares {
+		// Simulate a restart by reopening the store
+		store, err := noncestore.NewEncryptedFileStore(dirs[i], key)
+		require.NoError(t, err)
+		state := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		require.NoError(t, state.LoadNonce(store, commitments))
+		shares[i], err = state.Sign(commitments)
+		require.NoError(t, err)
+
+		// The same nonce cannot be loaded again, even after another restart
+		store, err = noncestore.NewEncryptedFileStore(dirs[i], key)
+		require.NoError(t, err)
+		again := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, []byte("other"), keygen.ParticipantPrivateKeys[i])
+		require.ErrorIs(t, again.LoadNonce(store, commitments), frost.ErrNonceReused)
+		n, err := store.Len()
+		require.NoError(t, err)
+		require.Equal(t, 4, n)
+	}
+
+	aggState := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, nil)
+	_, err = aggState.Sign(commitments)
+	require.NoError(t, err)
+	sig, err := aggState.Aggregate(shares)
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+
+	store := noncestore.NewMemoryStore()
+	pool, err := frost.Preprocess(c, keygen.ParticipantPrivateKeys[0], 3, store)
+	require.NoError(t, err)
+	require.Equal(t, 3, store.Len())
+
+	_, err = store.Take(pool[1])
+	require.NoError(t, err)
+	_, err = store.Take(pool[1])
+	require.ErrorIs(t, err, frost.ErrNonceReused)
+
+	other := noncestore.NewMemoryStore()
+	_, err = other.Take(pool[0])
+	require.ErrorIs(t, err, frost.ErrNonceNotFound)
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, noncestore.KeySize)
+
+	_, err = noncestore.NewEncryptedFileStore(dir, key[:16])
+	require.Error(t, err)
+	store, err := noncestore.NewEncryptedFileStore(dir, key)
+	require.NoError(t, err)
+	nonce := randomNonce(t)
+	hiding := nonce.Hiding.Bytes()
+	pool, err := frost.Preprocess(c, keygen.ParticipantPrivateKeys[0], 2, store)
+	require.NoError(t, err)
+	state := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, nil, keygen.ParticipantPrivateKeys[0])
+	require.NoError(t, state.SetNonce(nonce))
+	require.NoError(t, store.Put(state.MyCommitment, &frost.Nonce{Hiding: nonce.Hiding, Binding: nonce.Binding}))
+
+	// Nothing on disk contains the nonce, and everything is private
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.False(t, bytes.Contains(contents, hiding))
+	}
+
+	// The wrong key cannot read a nonce, and the attempt still burns it
+	wrong, err := noncestore.NewEncryptedFileStore(dir, bytes.Repeat([]byte{8}, noncestore.KeySize))
+	require.NoError(t, err)
+	_, err = wrong.Take(pool[0])
+	require.Error(t, err)
+	_, err = store.Take(pool[0])
+	require.ErrorIs(t, err, frost.ErrNonceReused)
+
+	taken, err := store.Take(state.MyCommitment)
+	require.NoError(t, err)
+	require.Equal(t, hiding, taken.Hiding.Bytes())
+}
+
+func TestLoadNonceMismatch(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+
+	// A store that hands back the wrong nonce for a commitment
+	store := noncestore.NewMemoryStore()
+	pool, err := frost.Preprocess(c, keygen.ParticipantPrivateKeys[0], 1, store)
+	require.NoError(t, err)
+	wrong := randomNonce(t)
+	bad := noncestore.NewMemoryStore()
+	require.NoError(t, bad.Put(pool[0], wrong))
+
+	state := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, []byte("mismatch"), keygen.ParticipantPrivateKeys[0])
+	require.Error(t, state.LoadNonce(bad, pool))
+	require.Nil(t, state.MyCommitment)
+	require.True(t, wrong.Hiding.Equal(frost.NewScalar()))
+	_, err = state.Sign(pool)
+	require.ErrorIs(t, err, frost.ErrNonceReused)
+}
+
+// With an injected reader, preprocessing is deterministic, and makes the same
+// nonces as Commit given the same randomness.
+func TestPreprocessWithReader(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	share := keygen.ParticipantPrivateKeys[0]
+
+	pool, err := frost.PreprocessWithReader(c, share, 2, noncestore.NewMemoryStore(), &counterReader{n: 7})
+	require.NoError(t, err)
+	again, err := frost.PreprocessWithReader(c, share, 2, noncestore.NewMemoryStore(), &counterReader{n: 7})
+	require.NoError(t, err)
+	for i := range pool {
+		require.True(t, pool[i].Hiding.Equal(again[i].Hiding))
+		require.True(t, pool[i].Binding.Equal(again[i].Binding))
+	}
+	require.False(t, pool[0].Hiding.Equal(pool[1].Hiding))
+
+	state := frost.NewStateWithReader(c, keygen.Participants, keygen.GroupPublicKey, nil, share, &counterReader{n: 7})
+	com, err := state.Commit()
+	require.NoError(t, err)
+	require.True(t, com.Hiding.Equal(pool[0].Hiding))
+	require.True(t, com.Binding.Equal(pool[0].Binding))
+}
+
+func randomNonce(t *testing.T) *frost.Nonce {
+	scalar := func() *frost.Scalar {
+		b := make([]byte, 32)
+		_, err := rand.Read(b[:31])
+		require.NoError(t, err)
+		s, err := frost.ScalarFromBytes(b)
+		require.NoError(t, err)
+		return s
+	}
+	return &frost.Nonce{Hiding: scalar(), Binding: scalar()}
+}
@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRerandomizedFROST(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	message := []byte("unlinkable credential")
+
+	sign := func() (*frost.GroupKey, *frost.Signature) {
+		coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+		require.NoError(t, err)
+		coordinator.RandomizerSeed, err = frost.NewRandomizerSeed(nil)
+		require.NoError(t, err)
+
+		states := make([]*frost.State, 2)
+		for i := range states {
+			states[i] = frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+			commitment, err := states[i].Commit()
+			require.NoError(t, err)
+			require.NoError(t, coordinator.AddCommitment(commitment))
+		}
+		pkg, err := coordinator.SigningPackage()
+		require.NoError(t, err)
+
+		// The randomizer survives the trip to the signers
+		received, err := frost.SigningPackageFromBytes(pkg.Bytes())
+		require.NoError(t, err)
+		require.True(t, received.Randomizer.Equal(coordinator.Randomizer))
+
+		for _, state := range states {
+			share, err := state.SignPackage(received)
+			require.NoError(t, err)
+			require.NoError(t, coordinator.AddSignatureShare(share))
+			require.Equal(t, state.RandomizedGroupKey().Bytes(), frost.RandomizeGroupKey(keygen.GroupPublicKey, coordinator.Randomizer).Bytes())
+		}
+		sig, err := coordinator.Aggregate()
+		require.NoError(t, err)
+		return frost.RandomizeGroupKey(keygen.GroupPublicKey, coordinator.Randomizer), sig
+	}
+
+	key1, sig1 := sign()
+	key2, sig2 := sign()
+	require.NotEqual(t, key1.Bytes(), key2.Bytes())
+	require.NotEqual(t, keygen.GroupPublicKey.Bytes(), key1.Bytes())
+
+	for _, tc := range []struct {
+		key *frost.GroupKey
+		sig *frost.Signature
+	}{{key1, sig1}, {key2, sig2}} {
+		require.True(t, ed25519.Verify(tc.key.Bytes(), message, tc.sig.Bytes()))
+		require.False(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, tc.sig.Bytes()))
+		ok, err := frost.Verify(c, tc.key, message, tc.sig)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	require.False(t, ed25519.Verify(key2.Bytes(), message, sig1.Bytes()))
+}
+
+// The randomizer is bound to the signing package, so neither side can pick it.
+func TestRandomizerBinding(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	message := []byte("bound randomizer")
+
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+	require.NoError(t, err)
+	coordinator.RandomizerSeed, err = frost.NewRandomizerSeed(nil)
+	require.NoError(t, err)
+	states := make([]*frost.State, 2)
+	for i := range states {
+		states[i] = frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitment, err := states[i].Commit()
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddCommitment(commitment))
+	}
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+	require.True(t, pkg.Randomizer.Equal(frost.DeriveRandomizer(pkg.RandomizerSeed, pkg)))
+
+	// A randomizer chosen freely, or one that does not match its seed, is refused
+	other, err := frost.NewRandomizerSeed(nil)
+	require.NoError(t, err)
+	unbound := *pkg
+	unbound.RandomizerSeed = nil
+	_, err = states[0].SignPackage(&unbound)
+	require.Error(t, err)
+	mismatched := *pkg
+	mismatched.Randomizer = frost.DeriveRandomizer(other, pkg)
+	_, err = states[0].SignPackage(&mismatched)
+	require.Error(t, err)
+
+	// The randomizer changes with the message
+	changed := *pkg
+	changed.Message = []byte("other message")
+	require.False(t, pkg.Randomizer.Equal(frost.DeriveRandomizer(pkg.RandomizerSeed, &changed)))
+
+	for _, state := range states {
+		share, err := state.SignPackage(pkg)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddSignatureShare(share))
+	}
+	sig, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(frost.RandomizeGroupKey(keygen.GroupPublicKey, pkg.Randomizer).Bytes(), message, sig.Bytes()))
+
+	// A coordinator cannot set the randomizer directly
+	direct, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+	require.NoError(t, err)
+	direct.Randomizer = pkg.Randomizer
+	for _, com := range pkg.Commitments {
+		require.NoError(t, direct.AddCommitment(com))
+	}
+	_, err = direct.SigningPackage()
+	require.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/roast"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestROAST(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(5, 3)
+	require.NoError(t, err)
+	message := []byte("robust")
+
+	const (
+		malicious = 0
+		silent    = 1
+	)
+	coordinator, err := roast.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 3, message)
+	require.NoError(t, err)
+	signers := make([]*roast.Signer, 5)
+	var pending []*frost.SigningPackage
+	for i := range signers {
+		signers[i] = roast.NewSigner(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitment, err := signers[i].Commit()
+		require.NoError(t, err)
+		update, err := coordinator.AddCommitment(commitment)
+		require.NoError(t, err)
+		pending = append(pending, update.Sessions...)
+	}
+
+	var sig *frost.Signature
+	for rounds := 0; sig == nil; rounds++ {
+		require.Less(t, rounds, 100, "ROAST did not terminate")
+		require.NotEmpty(t, pending, "ROAST stalled")
+		pkg := pending[0]
+		pending = pending[1:]
+
+		for i, signer := range signers {
+			inSession := false
+			for _, com := range pkg.Commitments {
+				if com.Identifier.Equal(keygen.Participants[i].Identifier) {
+					inSession = true
+				}
+			}
+			if !inSession || i == silent {
+				continue
+			}
+			share, next, err := signer.Sign(pkg)
+			require.NoError(t, err)
+			if i == malicious {
+				share.Share = frost.NewScalar().Add(share.Share, share.Share)
+			}
+			update, err := coordinator.AddResponse(pkg.SessionID, share, next)
+			require.NoError(t, err)
+			pending = append(pending, update.Sessions...)
+			if update.Signature != nil {
+				sig = update.Signature
+				break
+			}
+		}
+	}
+
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+	require.Equal(t, sig, coordinator.Signature())
+	excluded := coordinator.Malicious()
+	require.Len(t, excluded, 1)
+	require.True(t, excluded[0].Equal(keygen.Participants[malicious].Identifier))
+}
+
+func TestROASTTooManyMalicious(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	message := []byte("doomed")
+
+	coordinator, err := roast.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+	require.NoError(t, err)
+	_, err = coordinator.AddCommitment(nil)
+	require.Error(t, err)
+	_, err = coordinator.AddCommitment(&frost.Commitment{})
+	require.Error(t, err)
+
+	signers := make([]*roast.Signer, 3)
+	var pkg *frost.SigningPackage
+	for i := range signers {
+		signers[i] = roast.NewSigner(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitment, err := signers[i].Commit()
+		require.NoError(t, err)
+		update, err := coordinator.AddCommitment(commitment)
+		require.NoError(t, err)
+		if len(update.Sessions) > 0 {
+			pkg = update.Sessions[0]
+		}
+	}
+	require.NotNil(t, pkg)
+
+	// Both signers in the first session send garbage
+	for i := range 2 {
+		share, next, err := signers[i].Sign(pkg)
+		require.NoError(t, err)
+		share.Share = frost.NewScalar()
+		_, err = coordinator.AddResponse(pkg.SessionID, share, next)
+		if i == 0 {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, roast.ErrTooManyMalicious)
+		}
+	}
+}
+
+// Commitments with identity elements are refused on arrival, so they cannot
+// break a session after its signers have been assigned to it.
+func TestROASTRejectsIdentityCommitments(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	message := []byte("identity")
+
+	_, err = roast.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 0, message)
+	require.Error(t, err)
+	_, err = roast.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 4, message)
+	require.Error(t, err)
+
+	coordinator, err := roast.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 2, message)
+	require.NoError(t, err)
+	signers := make([]*roast.Signer, 3)
+	for i := range signers {
+		signers[i] = roast.NewSigner(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+	}
+
+	bad, err := signers[0].Commit()
+	require.NoError(t, err)
+	bad.Hiding = frost.NewElement()
+	_, err = coordinator.AddCommitment(bad)
+	require.Error(t, err)
+
+	// The signer can still take part with a proper commitment
+	var pkg *frost.SigningPackage
+	for _, i := range []int{1, 2} {
+		com, err := signers[i].Commit()
+		require.NoError(t, err)
+		update, err := coordinator.AddCommitment(com)
+		require.NoError(t, err)
+		if len(update.Sessions) > 0 {
+			pkg = update.Sessions[0]
+		}
+	}
+	require.NotNil(t, pkg)
+
+	share, next, err := signers[1].Sign(pkg)
+	require.NoError(t, err)
+	next.Binding = frost.NewElement()
+	_, err = coordinator.AddResponse(pkg.SessionID, share, next)
+	require.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/pem"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/sharefile"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+// Cheap parameters, to keep the tests fast
+var testParams = sharefile.Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestShareFile(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	share := keygen.ParticipantPrivateKeys[1]
+	passphrase := []byte("correct horse battery staple")
+
+	file, err := sharefile.EncodeWithParams(share, keygen.GroupPublicKey, passphrase, testParams)
+	require.NoError(t, err)
+	block, _ := pem.Decode(file)
+	require.Equal(t, sharefile.PEMType, block.Type)
+	require.Equal(t, "FROST-ED25519-SHA512-v1", block.Headers["Suite"])
+	require.Equal(t, "argon2id,t=1,m=64,p=1", block.Headers["KDF"])
+	require.False(t, bytes.Contains(block.Bytes, share.Scalar.Bytes()))
+	require.False(t, bytes.Contains(block.Bytes, share.EncodeBinary()))
+
+	h, err := sharefile.ReadHeader(file)
+	require.NoError(t, err)
+	require.True(t, h.Identifier.Equal(share.Identifier))
+	require.True(t, h.Matches(keygen.GroupPublicKey))
+	other, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	require.False(t, h.Matches(other.GroupPublicKey))
+
+	got, h, err := sharefile.Read(bytes.NewReader(file), passphrase)
+	require.NoError(t, err)
+	require.Equal(t, share.EncodeBinary(), got.EncodeBinary())
+	require.Equal(t, testParams, h.Params)
+
+	_, _, err = sharefile.Decode(file, []byte("wrong horse"))
+	require.ErrorIs(t, err, sharefile.ErrWrongPassphrase)
+
+	// Flip a bit in the body
+	corrupted := bytes.Clone(block.Bytes)
+	corrupted[20] ^= 1
+	_, _, err = sharefile.Decode(pem.EncodeToMemory(&pem.Block{Type: block.Type, Headers: block.Headers, Bytes: corrupted}), passphrase)
+	require.ErrorIs(t, err, sharefile.ErrCorrupted)
+
+	// Edit a header
+	headers := map[string]string{}
+	for k, v := range block.Headers {
+		headers[k] = v
+	}
+	headers["Group-Key"] = sharefile.Fingerprint(other.GroupPublicKey)
+	_, _, err = sharefile.Decode(pem.EncodeToMemory(&pem.Block{Type: block.Type, Headers: headers, Bytes: block.Bytes}), passphrase)
+	require.ErrorIs(t, err, sharefile.ErrCorrupted)
+
+	// Truncation and garbage
+	_, _, err = sharefile.Decode(file[:len(file)/2], passphrase)
+	require.ErrorIs(t, err, sharefile.ErrCorrupted)
+	_, _, err = sharefile.Decode([]byte("not a share"), passphrase)
+	require.ErrorIs(t, err, sharefile.ErrCorrupted)
+
+	// Parameters that would exhaust memory are refused before any work
+	headers = map[string]string{}
+	for k, v := range block.Headers {
+		headers[k] = v
+	}
+	for _, kdf := range []string{"argon2id,t=1,m=4294967295,p=1", "argon2id,t=1,m=1048577,p=1", "argon2id,t=11,m=64,p=1"} {
+		headers["KDF"] = kdf
+		_, _, err = sharefile.Decode(pem.EncodeToMemory(&pem.Block{Type: block.Type, Headers: headers, Bytes: block.Bytes}), passphrase)
+		require.ErrorIs(t, err, sharefile.ErrCorrupted, kdf)
+	}
+	_, err = sharefile.EncodeWithParams(share, keygen.GroupPublicKey, passphrase, sharefile.Params{Time: 11, Memory: 64, Threads: 1})
+	require.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningPackage(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(4, 3)
+	require.NoError(t, err)
+	message := []byte("packaged")
+
+	coordinator, err := frost.NewCoordinator(c, keygen.Participants, keygen.GroupPublicKey, 3, message)
+	require.NoError(t, err)
+	coordinator.SessionID = []byte("session-42")
+	states := make([]*frost.State, 3)
+	for i := range states {
+		states[i] = frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i])
+		commitment, err := states[i].Commit()
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddCommitment(commitment))
+	}
+	pkg, err := coordinator.SigningPackage()
+	require.NoError(t, err)
+
+	encoded, err := pkg.EncodeJSON()
+	require.NoError(t, err)
+
+	// Bad packages are rejected without burning the nonce
+	bad, err := frost.SigningPackageFromJSON(encoded)
+	require.NoError(t, err)
+	bad.Message = []byte("something else")
+	_, err = states[0].SignPackage(bad)
+	require.Error(t, err)
+
+	bad, err = frost.SigningPackageFromBytes(pkg.Bytes())
+	require.NoError(t, err)
+	bad.Commitments[0].Hiding = bad.Commitments[1].Hiding
+	_, err = states[0].SignPackage(bad)
+	require.Error(t, err)
+	require.NotEqual(t, pkg.Hash(), bad.Hash())
+
+	bad, err = frost.SigningPackageFromBytes(pkg.Bytes())
+	require.NoError(t, err)
+	bad.Commitments = append(bad.Commitments, bad.Commitments[1])
+	_, err = states[0].SignPackage(bad)
+	require.Error(t, err)
+
+	bad, err = frost.SigningPackageFromBytes(pkg.Bytes())
+	require.NoError(t, err)
+	bad.Commitments = bad.Commitments[1:]
+	_, err = states[0].SignPackage(bad)
+	require.Error(t, err)
+
+	_, err = frost.SigningPackageFromBytes(pkg.Bytes()[:40])
+	require.Error(t, err)
+
+	for i, state := range states {
+		received, err := frost.SigningPackageFromJSON(encoded)
+		require.NoError(t, err)
+		require.Equal(t, []byte("session-42"), received.SessionID)
+		require.Equal(t, pkg.Hash(), received.Hash(), "signer %d got a different package", i)
+
+		share, err := state.SignPackage(received)
+		require.NoError(t, err)
+		require.NoError(t, coordinator.AddSignatureShare(share))
+	}
+
+	sig, err := coordinator.Aggregate()
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+}
+
+// Packages without the later extensions keep the original encoding.
+func TestSigningPackageLegacyEncoding(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	keygen, err := trusteddealer.NewTrustedDealer(c).Keygen(3, 2)
+	require.NoError(t, err)
+	state := frost.NewState(c, keygen.Participants, keygen.GroupPublicKey, []byte("m"), keygen.ParticipantPrivateKeys[0])
+	com, err := state.Commit()
+	require.NoError(t, err)
+	id, hiding, binding := com.Bytes()
+
+	legacy := []byte{0, 0, 0, 1, 's', 0, 0, 0, 1, 'm', 0, 0, 0, 1}
+	legacy = append(append(append(legacy, id...), hiding...), binding...)
+	pkg := &frost.SigningPackage{SessionID: []byte("s"), Message: []byte("m"), Commitments: []*frost.Commitment{com}}
+	require.Equal(t, legacy, pkg.Bytes())
+	decoded, err := frost.SigningPackageFromBytes(legacy)
+	require.NoError(t, err)
+	require.Nil(t, decoded.Randomizer)
+
+	// A bare randomizer is a trailing scalar, as before
+	alpha := keygen.ParticipantPrivateKeys[1].Scalar
+	pkg.Randomizer = alpha
+	require.Equal(t, append(append([]byte{}, legacy...), alpha.Bytes()...), pkg.Bytes())
+	decoded, err = frost.SigningPackageFromBytes(pkg.Bytes())
+	require.NoError(t, err)
+	require.True(t, decoded.Randomizer.Equal(alpha))
+
+	// Extensions use the versioned encoding
+	pkg.Randomizer = nil
+	pkg.DerivationPath = [][]byte{[]byte("child")}
+	extended := pkg.Bytes()
+	require.Equal(t, []byte{0xff, 0xff, 0xff, 0xff, 2}, extended[:5])
+	decoded, err = frost.SigningPackageFromBytes(extended)
+	require.NoError(t, err)
+	require.Equal(t, pkg.Hash(), decoded.Hash())
+	extended[4] = 3
+	_, err = frost.SigningPackageFromBytes(extended)
+	require.Error(t, err)
+}
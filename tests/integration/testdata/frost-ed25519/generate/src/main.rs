@@ -0,0 +1,72 @@
+//! Records a signing ceremony run entirely by frost-ed25519, for the zfrost
+//! tests to replay. Run from this directory with
+//!
+//!     cargo run > ../v2.1.0.json
+//!
+//! Every object is written in both of the crate's encodings: postcard (as
+//! hex) and serde JSON. The RNG is seeded, so the output is reproducible.
+
+use std::collections::BTreeMap;
+
+use frost_ed25519 as frost;
+use rand_chacha::ChaCha20Rng;
+use rand_core::SeedableRng;
+use serde::Serialize;
+use serde_json::{json, Map, Value};
+
+fn encode<T: Serialize>(v: &T) -> Value {
+    json!({
+        "binary": hex::encode(postcard::to_allocvec(v).expect("postcard encoding")),
+        "json": serde_json::to_string(v).expect("JSON encoding"),
+    })
+}
+
+fn by_identifier<T: Serialize>(m: &BTreeMap<frost::Identifier, T>) -> Value {
+    let mut out = Map::new();
+    for (id, v) in m {
+        out.insert(hex::encode(id.serialize()), encode(v));
+    }
+    Value::Object(out)
+}
+
+fn main() -> Result<(), frost::Error> {
+    let mut rng = ChaCha20Rng::seed_from_u64(9591);
+    let message = b"frost-ed25519 interop";
+
+    let (shares, public_key_package) =
+        frost::keys::generate_with_dealer(3, 2, frost::keys::IdentifierList::Default, &mut rng)?;
+    let mut key_packages = BTreeMap::new();
+    for (id, share) in shares {
+        key_packages.insert(id, frost::keys::KeyPackage::try_from(share)?);
+    }
+
+    // The first two participants sign
+    let signers: Vec<frost::Identifier> = key_packages.keys().take(2).cloned().collect();
+    let mut nonces = BTreeMap::new();
+    let mut commitments = BTreeMap::new();
+    for id in &signers {
+        let (n, c) = frost::round1::commit(key_packages[id].signing_share(), &mut rng);
+        nonces.insert(*id, n);
+        commitments.insert(*id, c);
+    }
+    let signing_package = frost::SigningPackage::new(commitments.clone(), message);
+    let mut signature_shares = BTreeMap::new();
+    for id in &signers {
+        let share = frost::round2::sign(&signing_package, &nonces[id], &key_packages[id])?;
+        signature_shares.insert(*id, share);
+    }
+    let signature = frost::aggregate(&signing_package, &signature_shares, &public_key_package)?;
+
+    let out = json!({
+        "crate": "frost-ed25519 2.1.0",
+        "message": hex::encode(message),
+        "public_key_package": encode(&public_key_package),
+        "key_packages": by_identifier(&key_packages),
+        "signing_commitments": by_identifier(&commitments),
+        "signing_package": encode(&signing_package),
+        "signature_shares": by_identifier(&signature_shares),
+        "signature": hex::encode(signature.serialize()?),
+    });
+    println!("{}", serde_json::to_string_pretty(&out).expect("JSON encoding"));
+    Ok(())
+}
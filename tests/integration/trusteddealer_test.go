@@ -82,3 +82,50 @@ func TestTrustedDealer(t *testing.T) {
 	ok := ed25519.Verify(pubKeyBytes, message, sigBytes)
 	require.True(t, ok)
 }
+
+// A deterministic stream of bytes for reproducible ceremonies
+type counterReader struct {
+	n byte
+}
+
+func (r *counterReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.n
+		r.n++
+	}
+	return len(p), nil
+}
+
+func TestDeterministicCeremony(t *testing.T) {
+	c := frost.DefaultCiphersuite()
+	message := []byte("reproducible")
+
+	run := func() []byte {
+		keygen, err := trusteddealer.NewTrustedDealerWithReader(c, &counterReader{}).Keygen(3, 2)
+		require.NoError(t, err)
+
+		states := make([]*frost.State, 2)
+		commitments := make([]*frost.Commitment, 2)
+		for i := range states {
+			states[i] = frost.NewStateWithReader(c, keygen.Participants, keygen.GroupPublicKey, message, keygen.ParticipantPrivateKeys[i], &counterReader{n: byte(100 * (i + 1))})
+			commitments[i], err = states[i].Commit()
+			require.NoError(t, err)
+		}
+		shares := make([]*frost.SignatureShare, 2)
+		for i := range states {
+			shares[i], err = states[i].Sign(commitments)
+			require.NoError(t, err)
+		}
+		sig, err := states[0].Aggregate(shares)
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(keygen.GroupPublicKey.Bytes(), message, sig.Bytes()))
+		return sig.Bytes()
+	}
+	require.Equal(t, run(), run())
+}
+
+func TestTrustedDealerNilReader(t *testing.T) {
+	keygen, err := trusteddealer.NewTrustedDealerWithReader(frost.DefaultCiphersuite(), nil).Keygen(3, 2)
+	require.NoError(t, err)
+	require.Len(t, keygen.Participants, 3)
+}
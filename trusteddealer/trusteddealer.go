@@ -1,12 +1,12 @@
-package trusteddealer
-
 // Package trusteddealer implements the "Trusted Dealer" key generation strategy from
 // Appendix C of RFC 9591.
 //
 // https://www.rfc-editor.org/rfc/rfc9591.html#name-trusted-dealer-key-generati
+package trusteddealer
 
 import (
 	"crypto/rand"
+	"io"
 	"math/big"
 
 	"filippo.io/edwards25519"
@@ -17,18 +17,28 @@ import (
 type KeygenOutput = frost.KeygenOutput
 
 type TrustedDealer struct {
-	c internal.Ciphersuite
+	c    internal.Ciphersuite
+	rand io.Reader
 }
 
 func NewTrustedDealer(c internal.Ciphersuite) *TrustedDealer {
-	return &TrustedDealer{c: c}
+	return &TrustedDealer{c: c, rand: rand.Reader}
+}
+
+// Use a caller-supplied source of randomness instead of crypto/rand. This is
+// only meant for reproducible tests. A nil reader means crypto/rand.
+func NewTrustedDealerWithReader(c internal.Ciphersuite, r io.Reader) *TrustedDealer {
+	if r == nil {
+		r = rand.Reader
+	}
+	return &TrustedDealer{c: c, rand: r}
 }
 
 // Implement the interface defined in ,,/keygen.go
 func (td *TrustedDealer) Keygen(maxParticipants, minParticipants uint32) (*KeygenOutput, error) {
 	// Generate a random secret key
 	secretKeyBytes := make([]byte, 64)
-	_, err := rand.Read(secretKeyBytes)
+	_, err := io.ReadFull(td.rand, secretKeyBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +51,7 @@ func (td *TrustedDealer) Keygen(maxParticipants, minParticipants uint32) (*Keyge
 	coefficients := make([]*edwards25519.Scalar, minParticipants-1)
 	for i := range coefficients {
 		randomBytes := make([]byte, 64)
-		_, err := rand.Read(randomBytes)
+		_, err := io.ReadFull(td.rand, randomBytes)
 		if err != nil {
 			return nil, err
 		}
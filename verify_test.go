@@ -0,0 +1,130 @@
+package frost_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/internal"
+	"github.com/soatok/frost/trusteddealer"
+	"github.com/stretchr/testify/require"
+)
+
+// Run a full 3-of-4 ceremony with a trusted dealer and return the signature
+func thresholdSign(t *testing.T, msg []byte) (*frost.KeygenOutput, *frost.Signature) {
+	t.Helper()
+	keygen, err := trusteddealer.NewTrustedDealer(csuite).Keygen(4, 3)
+	require.NoError(t, err)
+
+	states := make([]*frost.State, 3)
+	commitments := make([]*frost.Commitment, 3)
+	for i := range states {
+		states[i] = frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[i])
+		commitments[i], err = states[i].Commit()
+		require.NoError(t, err)
+	}
+	shares := make([]*frost.SignatureShare, 3)
+	for i := range states {
+		shares[i], err = states[i].Sign(commitments)
+		require.NoError(t, err)
+	}
+	sig, err := states[0].Aggregate(shares)
+	require.NoError(t, err)
+	return keygen, sig
+}
+
+func TestVerifyMatchesEd25519(t *testing.T) {
+	msg := []byte("verify me")
+	keygen, sig := thresholdSign(t, msg)
+	pk := keygen.GroupPublicKey.Bytes()
+	require.True(t, ed25519.Verify(pk, msg, sig.Bytes()))
+
+	parsed, err := frost.SignatureFromBytes(sig.Bytes())
+	require.NoError(t, err)
+	for _, mode := range []frost.VerifyMode{frost.VerifyCofactored, frost.VerifyCofactorless} {
+		ok, err := frost.VerifyWithMode(csuite, keygen.GroupPublicKey, msg, parsed, mode)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = frost.VerifyWithMode(csuite, keygen.GroupPublicKey, []byte("verify you"), parsed, mode)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+
+	// Signatures from crypto/ed25519 are accepted as well
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edSig, err := frost.SignatureFromBytes(ed25519.Sign(priv, msg))
+	require.NoError(t, err)
+	gk, err := frost.GroupKeyFromBytes(pub)
+	require.NoError(t, err)
+	ok, err := frost.Verify(csuite, gk, msg, edSig)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestSignatureFromBytesRejectsNonCanonical(t *testing.T) {
+	msg := []byte("canonical")
+	_, sig := thresholdSign(t, msg)
+	enc := sig.Bytes()
+
+	_, err := frost.SignatureFromBytes(enc[:63])
+	require.Error(t, err)
+
+	// z + L is the same scalar, but it is not reduced
+	order := csuite.Order()
+	z := sig.Z.BigInt()
+	z.Add(z, order)
+	zBytes := z.FillBytes(make([]byte, 32))
+	for i, j := 0, 31; i < j; i, j = i+1, j-1 {
+		zBytes[i], zBytes[j] = zBytes[j], zBytes[i]
+	}
+	malleated := append(append([]byte{}, enc[:32]...), zBytes...)
+	_, err = frost.SignatureFromBytes(malleated)
+	require.Error(t, err)
+
+	// The identity point has a non-canonical encoding with y = p + 1
+	nonCanonicalR, _ := hex.DecodeString("eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+	_, err = frost.SignatureFromBytes(append(nonCanonicalR, enc[32:]...))
+	require.Error(t, err)
+}
+
+func TestVerifyCofactoredAcceptsTorsion(t *testing.T) {
+	// A point of order 8
+	torsionBytes, _ := hex.DecodeString("c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a")
+	torsion, err := frost.ElementFromBytes(torsionBytes)
+	require.NoError(t, err)
+
+	secret, err := internal.RandomScalar()
+	require.NoError(t, err)
+	pk := frost.NewElement().Mul(secret, nil)
+	pk.Add(pk, torsion)
+	gk := &frost.GroupKey{Element: pk}
+
+	for i := byte(0); ; i++ {
+		msg := []byte{'t', i}
+		r, err := internal.RandomScalar()
+		require.NoError(t, err)
+		R := frost.NewElement().Mul(r, nil)
+		c := internal.ComputeChallenge(csuite, R, pk, msg)
+		if c.Bytes()[0]&7 == 0 {
+			// [c]T is the identity, so both equations would agree
+			continue
+		}
+		z := frost.NewScalar().Mul(c, secret)
+		z.Add(z, r)
+		sig := &frost.Signature{R: R, Z: z}
+
+		ok, err := frost.VerifyWithMode(csuite, gk, msg, sig, frost.VerifyCofactored)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = frost.VerifyWithMode(csuite, gk, msg, sig, frost.VerifyCofactorless)
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.False(t, ed25519.Verify(pk.Bytes(), msg, sig.Bytes()))
+		return
+	}
+}
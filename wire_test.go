@@ -0,0 +1,185 @@
+package frost_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/soatok/frost"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWireRoundTrip(t *testing.T) {
+	msg := []byte("wire")
+	keygen, sig := thresholdSign(t, msg)
+
+	state := frost.NewState(csuite, keygen.Participants, keygen.GroupPublicKey, msg, keygen.ParticipantPrivateKeys[0])
+	com, err := state.Commit()
+	require.NoError(t, err)
+	share := &frost.SignatureShare{Identifier: com.Identifier, Share: sig.Z}
+
+	check := func(enc []byte, size int, decode func([]byte) ([]byte, error)) {
+		t.Helper()
+		require.Len(t, enc, size)
+		again, err := decode(enc)
+		require.NoError(t, err)
+		require.Equal(t, enc, again)
+
+		// Truncation, trailing bytes, and a wrong version, suite or type
+		_, err = decode(enc[:len(enc)-1])
+		require.Error(t, err)
+		_, err = decode(append(append([]byte{}, enc...), 0))
+		require.Error(t, err)
+		for i := 0; i < 3; i++ {
+			bad := append([]byte{}, enc...)
+			bad[i] ^= 0x40
+			_, err = decode(bad)
+			require.Error(t, err)
+		}
+	}
+
+	check(sig.Z.EncodeBinary(), 35, func(b []byte) ([]byte, error) {
+		v, err := frost.ScalarFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+	check(sig.R.EncodeBinary(), 35, func(b []byte) ([]byte, error) {
+		v, err := frost.ElementFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+	check(keygen.GroupPublicKey.EncodeBinary(), 35, func(b []byte) ([]byte, error) {
+		v, err := frost.GroupKeyFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+	check(keygen.Participants[2].EncodeBinary(), 67, func(b []byte) ([]byte, error) {
+		v, err := frost.ParticipantFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+	check(keygen.ParticipantPrivateKeys[2].EncodeBinary(), 67, func(b []byte) ([]byte, error) {
+		v, err := frost.SecretShareFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+	check(com.EncodeBinary(), 99, func(b []byte) ([]byte, error) {
+		v, err := frost.CommitmentFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+	check(share.EncodeBinary(), 67, func(b []byte) ([]byte, error) {
+		v, err := frost.SignatureShareFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+	check(sig.EncodeBinary(), 67, func(b []byte) ([]byte, error) {
+		v, err := frost.SignatureFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+	check(frost.EncodeVSSCommitment(keygen.VssCommitment), 3+4+32*3, func(b []byte) ([]byte, error) {
+		v, err := frost.VSSCommitmentFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return frost.EncodeVSSCommitment(v), nil
+	})
+	check(keygen.EncodeBinary(), 3+4+64*4+4+64*4+32+4+32*3, func(b []byte) ([]byte, error) {
+		v, err := frost.KeygenOutputFromBinary(b)
+		if err != nil {
+			return nil, err
+		}
+		return v.EncodeBinary(), nil
+	})
+
+	pkg := &frost.SigningPackage{SessionID: []byte{1}, Message: msg, Commitments: []*frost.Commitment{com}}
+	enc := pkg.EncodeBinary()
+	decoded, err := frost.SigningPackageFromBinary(enc)
+	require.NoError(t, err)
+	require.Equal(t, enc, decoded.EncodeBinary())
+}
+
+func TestWireRejectsNonCanonical(t *testing.T) {
+	_, sig := thresholdSign(t, []byte("canonical wire"))
+
+	// A non-canonical encoding of the identity, and an unreduced scalar
+	nonCanonicalPoint, _ := hex.DecodeString("eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+	unreduced, _ := hex.DecodeString("edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010")
+
+	enc := sig.EncodeBinary()
+	bad := append(append(append([]byte{}, enc[:3]...), nonCanonicalPoint...), enc[35:]...)
+	_, err := frost.SignatureFromBinary(bad)
+	require.Error(t, err)
+	bad = append(append([]byte{}, enc[:35]...), unreduced...)
+	_, err = frost.SignatureFromBinary(bad)
+	require.Error(t, err)
+
+	// Zero identifiers and identity commitments
+	share := &frost.SignatureShare{Identifier: frost.NewScalar(), Share: sig.Z}
+	_, err = frost.SignatureShareFromBinary(share.EncodeBinary())
+	require.Error(t, err)
+	com := &frost.Commitment{Identifier: sig.Z, Hiding: frost.NewElement(), Binding: sig.R}
+	_, err = frost.CommitmentFromBinary(com.EncodeBinary())
+	require.Error(t, err)
+	_, err = frost.GroupKeyFromBinary((&frost.GroupKey{Element: frost.NewElement()}).EncodeBinary())
+	require.Error(t, err)
+
+	// Key generation output with an identity group key or without a VSS commitment
+	keygen, _ := thresholdSign(t, []byte("keygen"))
+	identity := *keygen
+	identity.GroupPublicKey = &frost.GroupKey{Element: frost.NewElement()}
+	_, err = frost.KeygenOutputFromBinary(identity.EncodeBinary())
+	require.Error(t, err)
+	noVSS := *keygen
+	noVSS.VssCommitment = nil
+	_, err = frost.KeygenOutputFromBinary(noVSS.EncodeBinary())
+	require.Error(t, err)
+
+	// Signing packages with an invalid, repeated or out-of-order commitment
+	other := &frost.Commitment{Identifier: frost.NewScalar().Add(sig.Z, sig.Z), Hiding: sig.R, Binding: sig.R}
+	valid := &frost.Commitment{Identifier: sig.Z, Hiding: sig.R, Binding: sig.R}
+	pkg := &frost.SigningPackage{Message: []byte("m"), Commitments: []*frost.Commitment{com}}
+	_, err = frost.SigningPackageFromBinary(pkg.EncodeBinary())
+	require.Error(t, err)
+	pkg.Commitments = []*frost.Commitment{valid, valid}
+	_, err = frost.SigningPackageFromBinary(pkg.EncodeBinary())
+	require.Error(t, err)
+	pkg.Commitments = []*frost.Commitment{valid, other}
+	enc = pkg.EncodeBinary()
+	_, err = frost.SigningPackageFromBinary(enc)
+	require.NoError(t, err)
+	swapped := append([]byte{}, enc...)
+	copy(swapped[3+13:], enc[3+13+96:3+13+192])
+	copy(swapped[3+13+96:], enc[3+13:3+13+96])
+	_, err = frost.SigningPackageFromBinary(swapped)
+	require.Error(t, err)
+	bad = append([]byte{}, enc...)
+	copy(bad[3+13+32:], nonCanonicalPoint)
+	_, err = frost.SigningPackageFromBinary(bad)
+	require.Error(t, err)
+
+	// A list length that does not match the input
+	vss := frost.EncodeVSSCommitment([]*frost.Element{sig.R})
+	vss[6] = 2
+	_, err = frost.VSSCommitmentFromBinary(vss)
+	require.Error(t, err)
+	vss[3] = 0xff
+	_, err = frost.VSSCommitmentFromBinary(vss)
+	require.Error(t, err)
+}
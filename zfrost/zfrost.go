@@ -0,0 +1,858 @@
+// Package zfrost converts between this package's types and the serialization
+// formats of the Zcash Foundation's frost-ed25519 Rust crate (frost-core
+// 2.x), so that Go and Rust signers can take part in the same ceremony.
+//
+// Both of the crate's formats are supported: serde JSON, and the binary
+// format produced by its serialize() methods (postcard). Every object starts
+// with a header holding the format version (0) and the ciphersuite: its ID
+// string in JSON, or the big-endian CRC-32 of the ID in binary. Scalars and
+// points are hex strings in JSON and raw 32-byte arrays in binary; byte
+// strings and map sizes are varint length-prefixed in binary.
+//
+// The crate's SigningCommitments and SignatureShare carry no identifier (it
+// is the key of the map they are stored in), so decoding them takes the
+// identifier as a parameter.
+package zfrost
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"sort"
+
+	"github.com/soatok/frost"
+	"github.com/soatok/frost/internal"
+)
+
+const (
+	// Version is the serialization format version of frost-core 2.x.
+	Version = 0
+
+	// CiphersuiteID is the crate's ID for FROST(Ed25519, SHA-512).
+	CiphersuiteID = internal.ContextString
+)
+
+// The binary header: the version and the CRC-32 of the ciphersuite ID.
+func header() []byte {
+	return binary.BigEndian.AppendUint32([]byte{Version}, crc32.ChecksumIEEE([]byte(CiphersuiteID)))
+}
+
+type headerJSON struct {
+	Version     uint8  `json:"version"`
+	Ciphersuite string `json:"ciphersuite"`
+}
+
+func newHeaderJSON() headerJSON {
+	return headerJSON{Version: Version, Ciphersuite: CiphersuiteID}
+}
+
+func (h headerJSON) check() error {
+	if h.Version != Version {
+		return fmt.Errorf("unsupported serialization version %d", h.Version)
+	}
+	if h.Ciphersuite != CiphersuiteID {
+		return fmt.Errorf("wrong ciphersuite %q", h.Ciphersuite)
+	}
+	return nil
+}
+
+// reader decodes the binary format. The first error sticks.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.b) {
+		r.fail("unexpected end of input")
+		return nil
+	}
+	out := r.b[:n]
+	r.b = r.b[n:]
+	return out
+}
+
+func (r *reader) header() {
+	if h := r.next(5); h != nil && !bytes.Equal(h, header()) {
+		if h[0] != Version {
+			r.fail("unsupported serialization version %d", h[0])
+		} else {
+			r.fail("wrong ciphersuite")
+		}
+	}
+}
+
+// Read a postcard varint (LEB128) of at most 64 bits.
+func (r *reader) varint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.fail("malformed varint")
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+// Read a collection length, refusing lengths that cannot fit in the rest of
+// the input at size bytes per item.
+func (r *reader) length(size int) int {
+	n := r.varint()
+	if r.err == nil && n > uint64(len(r.b))/uint64(size) {
+		r.fail("length exceeds input")
+		return 0
+	}
+	return int(n)
+}
+
+func (r *reader) scalar() *frost.Scalar {
+	b := r.next(32)
+	if b == nil {
+		return nil
+	}
+	s, err := scalar(b)
+	if err != nil {
+		r.fail("%w", err)
+	}
+	return s
+}
+
+func (r *reader) identifier() *frost.Scalar {
+	b := r.next(32)
+	if b == nil {
+		return nil
+	}
+	s, err := identifier(b)
+	if err != nil {
+		r.fail("%w", err)
+	}
+	return s
+}
+
+func (r *reader) element() *frost.Element {
+	b := r.next(32)
+	if b == nil {
+		return nil
+	}
+	e, err := element(b)
+	if err != nil {
+		r.fail("%w", err)
+	}
+	return e
+}
+
+func (r *reader) done() error {
+	if r.err == nil && len(r.b) != 0 {
+		r.fail("trailing bytes")
+	}
+	return r.err
+}
+
+func scalar(b []byte) (*frost.Scalar, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("invalid scalar length: %d", len(b))
+	}
+	return frost.ScalarFromBytes(b)
+}
+
+func identifier(b []byte) (*frost.Scalar, error) {
+	s, err := scalar(b)
+	if err != nil {
+		return nil, err
+	}
+	if s.Equal(frost.NewScalar()) {
+		return nil, fmt.Errorf("zero identifier")
+	}
+	return s, nil
+}
+
+func element(b []byte) (*frost.Element, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("invalid point length: %d", len(b))
+	}
+	e, err := frost.ElementFromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(e.Bytes(), b) {
+		return nil, fmt.Errorf("non-canonical point encoding")
+	}
+	return e, nil
+}
+
+func hexScalar(s string) (*frost.Scalar, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return scalar(b)
+}
+
+func hexIdentifier(s string) (*frost.Scalar, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return identifier(b)
+}
+
+// Map keys must be in the crate's encoding, lower-case hex, so that each
+// identifier has exactly one key.
+func hexKey(s string) (*frost.Scalar, error) {
+	id, err := hexIdentifier(s)
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(id.Bytes()) != s {
+		return nil, fmt.Errorf("non-canonical identifier key %q", s)
+	}
+	return id, nil
+}
+
+func hexElement(s string) (*frost.Element, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return element(b)
+}
+
+// Sort identifiers numerically, which is the order of the crate's BTreeMaps.
+func sortByIdentifier(n int, id func(i int) *frost.Scalar, swap func(i, j int)) {
+	sort.Sort(byIdentifier{n, id, swap})
+}
+
+type byIdentifier struct {
+	n    int
+	id   func(i int) *frost.Scalar
+	swap func(i, j int)
+}
+
+func (s byIdentifier) Len() int           { return s.n }
+func (s byIdentifier) Swap(i, j int)      { s.swap(i, j) }
+func (s byIdentifier) Less(i, j int) bool { return lessIdentifier(s.id(i), s.id(j)) }
+
+func lessIdentifier(x, y *frost.Scalar) bool {
+	// Identifiers are little-endian, so compare from the last byte
+	a, b := x.Bytes(), y.Bytes()
+	for k := len(a) - 1; k >= 0; k-- {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return false
+}
+
+// Read a map key. The crate writes its BTreeMaps in order, so keys must be
+// strictly ascending; this also refuses repeated identifiers.
+func (r *reader) key(prev *frost.Scalar) *frost.Scalar {
+	id := r.identifier()
+	if r.err == nil && prev != nil && !lessIdentifier(prev, id) {
+		r.fail("identifiers out of order or repeated")
+	}
+	return id
+}
+
+// MarshalSigningCommitments encodes a commitment as the crate's binary
+// SigningCommitments.
+func MarshalSigningCommitments(com *frost.Commitment) []byte {
+	return appendSigningCommitments(nil, com)
+}
+
+func appendSigningCommitments(out []byte, com *frost.Commitment) []byte {
+	out = append(out, header()...)
+	out = append(out, com.Hiding.Bytes()...)
+	return append(out, com.Binding.Bytes()...)
+}
+
+func (r *reader) signingCommitments(id *frost.Scalar) *frost.Commitment {
+	r.header()
+	com := &frost.Commitment{Identifier: id, Hiding: r.element(), Binding: r.element()}
+	if r.err == nil && (com.Hiding.IsIdentity() || com.Binding.IsIdentity()) {
+		r.fail("commitment contains the identity element")
+	}
+	return com
+}
+
+// UnmarshalSigningCommitments decodes the crate's binary SigningCommitments
+// for the participant with the given identifier.
+func UnmarshalSigningCommitments(id *frost.Scalar, b []byte) (*frost.Commitment, error) {
+	r := &reader{b: b}
+	com := r.signingCommitments(id)
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return com, nil
+}
+
+type signingCommitmentsJSON struct {
+	Header  headerJSON `json:"header"`
+	Hiding  string     `json:"hiding"`
+	Binding string     `json:"binding"`
+}
+
+func newSigningCommitmentsJSON(com *frost.Commitment) signingCommitmentsJSON {
+	return signingCommitmentsJSON{
+		Header:  newHeaderJSON(),
+		Hiding:  hex.EncodeToString(com.Hiding.Bytes()),
+		Binding: hex.EncodeToString(com.Binding.Bytes()),
+	}
+}
+
+func (v signingCommitmentsJSON) commitment(id *frost.Scalar) (*frost.Commitment, error) {
+	if err := v.Header.check(); err != nil {
+		return nil, err
+	}
+	hiding, err := hexElement(v.Hiding)
+	if err != nil {
+		return nil, err
+	}
+	binding, err := hexElement(v.Binding)
+	if err != nil {
+		return nil, err
+	}
+	if hiding.IsIdentity() || binding.IsIdentity() {
+		return nil, fmt.Errorf("commitment contains the identity element")
+	}
+	return &frost.Commitment{Identifier: id, Hiding: hiding, Binding: binding}, nil
+}
+
+// MarshalSigningCommitmentsJSON encodes a commitment as the crate's JSON
+// SigningCommitments.
+func MarshalSigningCommitmentsJSON(com *frost.Commitment) ([]byte, error) {
+	return json.Marshal(newSigningCommitmentsJSON(com))
+}
+
+// UnmarshalSigningCommitmentsJSON decodes the crate's JSON SigningCommitments
+// for the participant with the given identifier.
+func UnmarshalSigningCommitmentsJSON(id *frost.Scalar, j []byte) (*frost.Commitment, error) {
+	var v signingCommitmentsJSON
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+	return v.commitment(id)
+}
+
+// The crate's SigningPackage has no room for the optional features of ours.
+func checkPortable(pkg *frost.SigningPackage) error {
+	if len(pkg.SessionID) > 0 || pkg.Randomizer != nil || pkg.RandomizerSeed != nil || len(pkg.DerivationPath) > 0 || pkg.AdaptorPoint != nil || pkg.BlindChallenge != nil {
+		return fmt.Errorf("signing package uses features frost-ed25519 does not support")
+	}
+	return nil
+}
+
+func sortedCommitments(pkg *frost.SigningPackage) []*frost.Commitment {
+	sorted := append([]*frost.Commitment{}, pkg.Commitments...)
+	sortByIdentifier(len(sorted), func(i int) *frost.Scalar { return sorted[i].Identifier }, func(i, j int) {
+		sorted[i], sorted[j] = sorted[j], sorted[i]
+	})
+	return sorted
+}
+
+// MarshalSigningPackage encodes a signing package in the crate's binary
+// format. Session IDs, randomizers, derivation paths, adaptor points and
+// blind challenges cannot be represented.
+func MarshalSigningPackage(pkg *frost.SigningPackage) ([]byte, error) {
+	if err := checkPortable(pkg); err != nil {
+		return nil, err
+	}
+	out := header()
+	sorted := sortedCommitments(pkg)
+	out = binary.AppendUvarint(out, uint64(len(sorted)))
+	for _, com := range sorted {
+		out = append(out, com.Identifier.Bytes()...)
+		out = appendSigningCommitments(out, com)
+	}
+	out = binary.AppendUvarint(out, uint64(len(pkg.Message)))
+	return append(out, pkg.Message...), nil
+}
+
+// UnmarshalSigningPackage decodes a signing package in the crate's binary
+// format.
+func UnmarshalSigningPackage(b []byte) (*frost.SigningPackage, error) {
+	r := &reader{b: b}
+	r.header()
+	pkg := new(frost.SigningPackage)
+	n := r.length(32 + 5 + 64)
+	var id *frost.Scalar
+	for i := 0; i < n && r.err == nil; i++ {
+		id = r.key(id)
+		pkg.Commitments = append(pkg.Commitments, r.signingCommitments(id))
+	}
+	pkg.Message = append([]byte{}, r.next(r.length(1))...)
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// Decode a JSON object keyed by hex identifiers, calling value for each entry.
+// Going through the tokens rather than a Go map means repeated keys are seen
+// and refused, as the binary decoders refuse repeated identifiers.
+func decodeIdentifierMap(j json.RawMessage, value func(id *frost.Scalar, v json.RawMessage) error) error {
+	dec := json.NewDecoder(bytes.NewReader(j))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object keyed by identifiers")
+	}
+	seen := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		if seen[key] {
+			return fmt.Errorf("repeated identifier %q", key)
+		}
+		seen[key] = true
+		id, err := hexKey(key)
+		if err != nil {
+			return err
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if err := value(id, v); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// JSON objects are maps keyed by hex identifiers. encoding/json sorts map
+// keys as strings, which is not the crate's order, so they are written by
+// hand.
+type orderedMap []struct {
+	key   string
+	value any
+}
+
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(kv.key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(kv.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type signingPackageJSON struct {
+	Header             headerJSON `json:"header"`
+	SigningCommitments any        `json:"signing_commitments"`
+	Message            string     `json:"message"`
+}
+
+// MarshalSigningPackageJSON encodes a signing package in the crate's JSON
+// format.
+func MarshalSigningPackageJSON(pkg *frost.SigningPackage) ([]byte, error) {
+	if err := checkPortable(pkg); err != nil {
+		return nil, err
+	}
+	var commitments orderedMap
+	for _, com := range sortedCommitments(pkg) {
+		commitments = append(commitments, struct {
+			key   string
+			value any
+		}{hex.EncodeToString(com.Identifier.Bytes()), newSigningCommitmentsJSON(com)})
+	}
+	return json.Marshal(signingPackageJSON{
+		Header:             newHeaderJSON(),
+		SigningCommitments: commitments,
+		Message:            hex.EncodeToString(pkg.Message),
+	})
+}
+
+// UnmarshalSigningPackageJSON decodes a signing package in the crate's JSON
+// format.
+func UnmarshalSigningPackageJSON(j []byte) (*frost.SigningPackage, error) {
+	var v struct {
+		Header             headerJSON      `json:"header"`
+		SigningCommitments json.RawMessage `json:"signing_commitments"`
+		Message            string          `json:"message"`
+	}
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+	if err := v.Header.check(); err != nil {
+		return nil, err
+	}
+	msg, err := hex.DecodeString(v.Message)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &frost.SigningPackage{Message: msg}
+	err = decodeIdentifierMap(v.SigningCommitments, func(id *frost.Scalar, j json.RawMessage) error {
+		var c signingCommitmentsJSON
+		if err := json.Unmarshal(j, &c); err != nil {
+			return err
+		}
+		com, err := c.commitment(id)
+		if err != nil {
+			return err
+		}
+		pkg.Commitments = append(pkg.Commitments, com)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	pkg.Commitments = sortedCommitments(pkg)
+	return pkg, nil
+}
+
+// MarshalSignatureShare encodes a signature share in the crate's binary
+// format. The identifier is not part of the encoding.
+func MarshalSignatureShare(share *frost.SignatureShare) []byte {
+	return append(header(), share.Share.Bytes()...)
+}
+
+// UnmarshalSignatureShare decodes a signature share in the crate's binary
+// format, from the participant with the given identifier.
+func UnmarshalSignatureShare(id *frost.Scalar, b []byte) (*frost.SignatureShare, error) {
+	r := &reader{b: b}
+	r.header()
+	share := &frost.SignatureShare{Identifier: id, Share: r.scalar()}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+type signatureShareJSON struct {
+	Header headerJSON `json:"header"`
+	Share  string     `json:"share"`
+}
+
+// MarshalSignatureShareJSON encodes a signature share in the crate's JSON
+// format.
+func MarshalSignatureShareJSON(share *frost.SignatureShare) ([]byte, error) {
+	return json.Marshal(signatureShareJSON{Header: newHeaderJSON(), Share: hex.EncodeToString(share.Share.Bytes())})
+}
+
+// UnmarshalSignatureShareJSON decodes a signature share in the crate's JSON
+// format, from the participant with the given identifier.
+func UnmarshalSignatureShareJSON(id *frost.Scalar, j []byte) (*frost.SignatureShare, error) {
+	var v signatureShareJSON
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+	if err := v.Header.check(); err != nil {
+		return nil, err
+	}
+	s, err := hexScalar(v.Share)
+	if err != nil {
+		return nil, err
+	}
+	return &frost.SignatureShare{Identifier: id, Share: s}, nil
+}
+
+// KeyPackage is the crate's KeyPackage: everything one participant needs to
+// sign, except the other participants' public key shares, which are in the
+// PublicKeyPackage.
+//
+// Like the crate, decoding a KeyPackage only checks the encoding of each
+// field. Call Validate before signing with an imported KeyPackage.
+type KeyPackage struct {
+	SecretShare    *frost.SecretShare
+	VerifyingShare *frost.Element
+	GroupKey       *frost.GroupKey
+	MinSigners     uint16
+}
+
+// NewKeyPackage extracts the i-th participant's KeyPackage from key
+// generation output with the given threshold.
+func NewKeyPackage(ko *frost.KeygenOutput, i int, minSigners uint16) *KeyPackage {
+	ss := ko.ParticipantPrivateKeys[i]
+	var verifyingShare *frost.Element
+	for _, p := range ko.Participants {
+		if p.Identifier.Equal(ss.Identifier) {
+			verifyingShare = p.PublicKeyShare
+		}
+	}
+	return &KeyPackage{SecretShare: ss, VerifyingShare: verifyingShare, GroupKey: ko.GroupPublicKey, MinSigners: minSigners}
+}
+
+// Validate checks that the verifying share belongs to the signing share, that
+// the group key is not the identity, and that the threshold is at least 2.
+func (kp *KeyPackage) Validate() error {
+	if !frost.NewElement().Mul(kp.SecretShare.Scalar, nil).Equal(kp.VerifyingShare) {
+		return fmt.Errorf("verifying share does not match signing share")
+	}
+	if kp.GroupKey.Element.IsIdentity() {
+		return fmt.Errorf("group key is the identity")
+	}
+	if kp.MinSigners < 2 {
+		return fmt.Errorf("min_signers must be at least 2")
+	}
+	return nil
+}
+
+// Marshal encodes the KeyPackage in the crate's binary format. The output is
+// secret.
+func (kp *KeyPackage) Marshal() []byte {
+	out := header()
+	out = append(out, kp.SecretShare.Identifier.Bytes()...)
+	out = append(out, kp.SecretShare.Scalar.Bytes()...)
+	out = append(out, kp.VerifyingShare.Bytes()...)
+	out = append(out, kp.GroupKey.Bytes()...)
+	return binary.AppendUvarint(out, uint64(kp.MinSigners))
+}
+
+// UnmarshalKeyPackage decodes a KeyPackage in the crate's binary format.
+func UnmarshalKeyPackage(b []byte) (*KeyPackage, error) {
+	r := &reader{b: b}
+	r.header()
+	kp := &KeyPackage{SecretShare: &frost.SecretShare{Identifier: r.identifier(), Scalar: r.scalar()}}
+	kp.VerifyingShare = r.element()
+	kp.GroupKey = &frost.GroupKey{Element: r.element()}
+	minSigners := r.varint()
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if minSigners > 0xffff {
+		return nil, fmt.Errorf("min_signers out of range")
+	}
+	kp.MinSigners = uint16(minSigners)
+	return kp, nil
+}
+
+type keyPackageJSON struct {
+	Header         headerJSON `json:"header"`
+	Identifier     string     `json:"identifier"`
+	SigningShare   string     `json:"signing_share"`
+	VerifyingShare string     `json:"verifying_share"`
+	VerifyingKey   string     `json:"verifying_key"`
+	MinSigners     uint16     `json:"min_signers"`
+}
+
+// MarshalJSON encodes the KeyPackage in the crate's JSON format. The output
+// is secret.
+func (kp *KeyPackage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keyPackageJSON{
+		Header:         newHeaderJSON(),
+		Identifier:     hex.EncodeToString(kp.SecretShare.Identifier.Bytes()),
+		SigningShare:   hex.EncodeToString(kp.SecretShare.Scalar.Bytes()),
+		VerifyingShare: hex.EncodeToString(kp.VerifyingShare.Bytes()),
+		VerifyingKey:   hex.EncodeToString(kp.GroupKey.Bytes()),
+		MinSigners:     kp.MinSigners,
+	})
+}
+
+// UnmarshalJSON decodes a KeyPackage in the crate's JSON format.
+func (kp *KeyPackage) UnmarshalJSON(j []byte) error {
+	var v keyPackageJSON
+	if err := json.Unmarshal(j, &v); err != nil {
+		return err
+	}
+	if err := v.Header.check(); err != nil {
+		return err
+	}
+	id, err := hexIdentifier(v.Identifier)
+	if err != nil {
+		return err
+	}
+	secret, err := hexScalar(v.SigningShare)
+	if err != nil {
+		return err
+	}
+	verifyingShare, err := hexElement(v.VerifyingShare)
+	if err != nil {
+		return err
+	}
+	gk, err := hexElement(v.VerifyingKey)
+	if err != nil {
+		return err
+	}
+	*kp = KeyPackage{
+		SecretShare:    &frost.SecretShare{Identifier: id, Scalar: secret},
+		VerifyingShare: verifyingShare,
+		GroupKey:       &frost.GroupKey{Element: gk},
+		MinSigners:     v.MinSigners,
+	}
+	return nil
+}
+
+// PublicKeyPackage is the crate's PublicKeyPackage: every participant's
+// public key share, and the group key. Newer versions of the crate also
+// record the threshold; MinSigners is 0 when it is absent.
+type PublicKeyPackage struct {
+	Participants []*frost.Participant
+	GroupKey     *frost.GroupKey
+	MinSigners   uint16
+}
+
+// NewPublicKeyPackage extracts the PublicKeyPackage from key generation
+// output.
+func NewPublicKeyPackage(ko *frost.KeygenOutput) *PublicKeyPackage {
+	return &PublicKeyPackage{Participants: ko.Participants, GroupKey: ko.GroupPublicKey}
+}
+
+func (pk *PublicKeyPackage) sorted() []*frost.Participant {
+	sorted := append([]*frost.Participant{}, pk.Participants...)
+	sortByIdentifier(len(sorted), func(i int) *frost.Scalar { return sorted[i].Identifier }, func(i, j int) {
+		sorted[i], sorted[j] = sorted[j], sorted[i]
+	})
+	return sorted
+}
+
+// Marshal encodes the PublicKeyPackage in the crate's binary format. The
+// threshold is only written if MinSigners is set.
+func (pk *PublicKeyPackage) Marshal() []byte {
+	out := header()
+	sorted := pk.sorted()
+	out = binary.AppendUvarint(out, uint64(len(sorted)))
+	for _, p := range sorted {
+		out = append(out, p.Identifier.Bytes()...)
+		out = append(out, p.PublicKeyShare.Bytes()...)
+	}
+	out = append(out, pk.GroupKey.Bytes()...)
+	if pk.MinSigners > 0 {
+		out = append(out, 1)
+		out = binary.AppendUvarint(out, uint64(pk.MinSigners))
+	}
+	return out
+}
+
+// UnmarshalPublicKeyPackage decodes a PublicKeyPackage in the crate's binary
+// format, with or without the trailing threshold.
+func UnmarshalPublicKeyPackage(b []byte) (*PublicKeyPackage, error) {
+	r := &reader{b: b}
+	r.header()
+	pk := new(PublicKeyPackage)
+	n := r.length(64)
+	var id *frost.Scalar
+	for i := 0; i < n && r.err == nil; i++ {
+		id = r.key(id)
+		pk.Participants = append(pk.Participants, &frost.Participant{Identifier: id, PublicKeyShare: r.element()})
+	}
+	pk.GroupKey = &frost.GroupKey{Element: r.element()}
+	if r.err == nil && len(r.b) > 0 {
+		// Option<u16>
+		switch r.next(1)[0] {
+		case 0:
+		case 1:
+			minSigners := r.varint()
+			if minSigners == 0 || minSigners > 0xffff {
+				r.fail("min_signers out of range")
+			}
+			pk.MinSigners = uint16(minSigners)
+		default:
+			r.fail("malformed min_signers")
+		}
+	}
+	if err := r.done(); err != nil {
+		return nil, err
+	}
+	if pk.GroupKey.Element.IsIdentity() {
+		return nil, fmt.Errorf("group key is the identity")
+	}
+	return pk, nil
+}
+
+type publicKeyPackageJSON struct {
+	Header          headerJSON `json:"header"`
+	VerifyingShares any        `json:"verifying_shares"`
+	VerifyingKey    string     `json:"verifying_key"`
+	MinSigners      *uint16    `json:"min_signers,omitempty"`
+}
+
+// MarshalJSON encodes the PublicKeyPackage in the crate's JSON format.
+func (pk *PublicKeyPackage) MarshalJSON() ([]byte, error) {
+	var shares orderedMap
+	for _, p := range pk.sorted() {
+		shares = append(shares, struct {
+			key   string
+			value any
+		}{hex.EncodeToString(p.Identifier.Bytes()), hex.EncodeToString(p.PublicKeyShare.Bytes())})
+	}
+	v := publicKeyPackageJSON{
+		Header:          newHeaderJSON(),
+		VerifyingShares: shares,
+		VerifyingKey:    hex.EncodeToString(pk.GroupKey.Bytes()),
+	}
+	if pk.MinSigners > 0 {
+		v.MinSigners = &pk.MinSigners
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a PublicKeyPackage in the crate's JSON format.
+func (pk *PublicKeyPackage) UnmarshalJSON(j []byte) error {
+	var v struct {
+		Header          headerJSON      `json:"header"`
+		VerifyingShares json.RawMessage `json:"verifying_shares"`
+		VerifyingKey    string          `json:"verifying_key"`
+		MinSigners      *uint16         `json:"min_signers"`
+	}
+	if err := json.Unmarshal(j, &v); err != nil {
+		return err
+	}
+	if err := v.Header.check(); err != nil {
+		return err
+	}
+	out := PublicKeyPackage{}
+	err := decodeIdentifierMap(v.VerifyingShares, func(id *frost.Scalar, j json.RawMessage) error {
+		var share string
+		if err := json.Unmarshal(j, &share); err != nil {
+			return err
+		}
+		e, err := hexElement(share)
+		if err != nil {
+			return err
+		}
+		out.Participants = append(out.Participants, &frost.Participant{Identifier: id, PublicKeyShare: e})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	out.Participants = out.sorted()
+	gk, err := hexElement(v.VerifyingKey)
+	if err != nil {
+		return err
+	}
+	if gk.IsIdentity() {
+		return fmt.Errorf("group key is the identity")
+	}
+	out.GroupKey = &frost.GroupKey{Element: gk}
+	if v.MinSigners != nil {
+		if *v.MinSigners == 0 {
+			return fmt.Errorf("min_signers out of range")
+		}
+		out.MinSigners = *v.MinSigners
+	}
+	*pk = out
+	return nil
+}